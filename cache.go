@@ -0,0 +1,615 @@
+package kyte
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/bsoncodec"
+)
+
+/*
+schemaCache holds the reflected schema of the source structs, keyed by reflect.Type and the struct tag parser.
+*/
+var schemaCache sync.Map
+
+// wildcard is the path segment of the values of a map field.
+const wildcard = "*"
+
+/*
+schemaOptions are the options that change the schema of a source type, they are a part of the cache key.
+*/
+type schemaOptions struct {
+	parser   bsoncodec.StructTagParser
+	maxDepth int
+}
+
+type schemaKey struct {
+	typ     reflect.Type
+	options schemaOptions
+}
+
+type fieldKey struct {
+	offset uintptr
+	typ    reflect.Type
+}
+
+type schemaField struct {
+	path   string
+	typ    reflect.Type
+	offset uintptr
+	index  []int
+}
+
+/*
+structSchema holds the fields of a struct and of its nested struct values, they are in the same memory block
+so they are indexed by their offset from the struct address and their type.
+The structs behind pointers, slices and arrays are kept as indirect schemas.
+*/
+type structSchema struct {
+	typ      reflect.Type
+	fields   []schemaField
+	offsets  map[fieldKey]int
+	paths    map[string]int
+	indirect []indirectField
+}
+
+/*
+indirectField is a pointer, a slice or an array of structs of the memory block, its fields are resolved against
+the pointed struct or the element of the instance that holds the field.
+*/
+type indirectField struct {
+	index []int
+	elem  *structSchema
+}
+
+type typeSchema struct {
+	*structSchema
+	names []string
+	types map[string]reflect.Type
+	// inlineMaps are the paths of the documents that have an inline map, any key is valid in these documents.
+	inlineMaps []string
+	// wildcards are the paths that have a wildcard segment, mapKeys are the key patterns of their map fields.
+	wildcards []string
+	mapKeys   map[string]*regexp.Regexp
+	// goPaths maps the paths of the Go field names to the bson paths and goNames is the reverse, the map values are the wildcard segment in both.
+	goPaths map[string]string
+	goNames map[string]string
+	// documents are the document fields of the struct types of the schema, they are walked by the values of the source such as an example.
+	documents map[reflect.Type][]documentField
+}
+
+/*
+documentField is a field of a document, its index passes through the inline fields of the struct.
+*/
+type documentField struct {
+	name  string
+	field reflect.StructField
+	index []int
+}
+
+type schemaBuilder struct {
+	options schemaOptions
+	schema  *typeSchema
+}
+
+/*
+getSchema returns the cached schema of the given struct type, the schema is built on the first call.
+The schemas of the parsers that are not comparable are not cached since they cannot be a part of the key.
+*/
+func getSchema(t reflect.Type, options schemaOptions) (*typeSchema, error) {
+	if options.parser != nil && !reflect.TypeOf(options.parser).Comparable() {
+		return buildSchema(t, options)
+	}
+
+	key := schemaKey{typ: t, options: options}
+	if s, ok := schemaCache.Load(key); ok {
+		return s.(*typeSchema), nil
+	}
+
+	s, err := buildSchema(t, options)
+	if err != nil {
+		return nil, err
+	}
+
+	cached, _ := schemaCache.LoadOrStore(key, s)
+	return cached.(*typeSchema), nil
+}
+
+func buildSchema(t reflect.Type, options schemaOptions) (*typeSchema, error) {
+	b := &schemaBuilder{
+		options: options,
+		schema: &typeSchema{
+			structSchema: newStructSchema(t),
+			types:        make(map[string]reflect.Type),
+			mapKeys:      make(map[string]*regexp.Regexp),
+			goPaths:      make(map[string]string),
+			goNames:      make(map[string]string),
+			documents:    make(map[reflect.Type][]documentField),
+		},
+	}
+
+	if err := b.addDocument(b.schema.structSchema, t, "", nil, 0, 0, []reflect.Type{t}); err != nil {
+		return nil, err
+	}
+
+	return b.schema, nil
+}
+
+func newStructSchema(t reflect.Type) *structSchema {
+	return &structSchema{typ: t, offsets: make(map[fieldKey]int), paths: make(map[string]int)}
+}
+
+/*
+addDocument adds the fields of the struct t that is placed at the given index and offset of the memory block,
+depth is the number of the path segments of the document and the stack holds the struct types that are being expanded.
+*/
+func (b *schemaBuilder) addDocument(block *structSchema, t reflect.Type, prefix string, index []int, offset uintptr, depth int, stack []reflect.Type) error {
+	if b.options.maxDepth > 0 && depth >= b.options.maxDepth {
+		return nil
+	}
+
+	fields, inlineMap, err := b.documentFields(t)
+	if err != nil {
+		return err
+	}
+
+	b.schema.documents[t] = fields
+	if inlineMap {
+		b.schema.inlineMaps = append(b.schema.inlineMaps, prefix)
+	}
+
+	for _, df := range fields {
+		if err := b.addField(block, t, df, prefix, index, offset, depth, stack); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+/*
+addField adds the document field to the memory block, an inline pointer on the way to the field moves it to an indirect schema.
+*/
+func (b *schemaBuilder) addField(block *structSchema, t reflect.Type, df documentField, prefix string, index []int, offset uintptr, depth int, stack []reflect.Type) error {
+	index = append([]int{}, index...)
+	for _, i := range df.index[:len(df.index)-1] {
+		inline := t.Field(i)
+		index = append(index, i)
+		offset += inline.Offset
+		t = inline.Type
+
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+			block, index, offset = block.indirectSchema(index, t), nil, 0
+		}
+	}
+
+	f := schemaField{
+		path:   prefix + df.name,
+		typ:    df.field.Type,
+		offset: offset + df.field.Offset,
+		index:  append(index, df.index[len(df.index)-1]),
+	}
+	block.add(f)
+	b.register(f.path, f.typ)
+	b.registerGoName(f.path, joinPath(b.schema.goNames[strings.TrimSuffix(prefix, ".")], df.field.Name))
+
+	if err := b.addMapKeys(df.field, f.path); err != nil {
+		return err
+	}
+
+	return b.addChildren(block, f.typ, f.path, f.index, f.offset, depth+1, stack)
+}
+
+/*
+addChildren adds the fields under the path of the type t. The struct values are in the same memory block, the structs behind
+pointers, slices and arrays are added to an indirect schema and the values of the maps are added under a wildcard segment.
+*/
+func (b *schemaBuilder) addChildren(block *structSchema, t reflect.Type, path string, index []int, offset uintptr, depth int, stack []reflect.Type) error {
+	if t.Kind() == reflect.Struct {
+		if b.isExpanded(t, stack) {
+			return nil
+		}
+		return b.addDocument(block, t, path+".", index, offset, depth, append(stack, t))
+	}
+
+	elem, ok := containerElem(t)
+	if !ok || b.isExpanded(elem, stack) {
+		return nil
+	}
+
+	switch {
+	case elem.Kind() == reflect.Struct:
+		return b.addDocument(block.indirectSchema(index, elem), elem, path+".", nil, 0, depth, append(stack, elem))
+	case elem.Kind() == reflect.Map && elem.Key().Kind() == reflect.String:
+		if b.options.maxDepth > 0 && depth >= b.options.maxDepth {
+			return nil
+		}
+
+		wildcardPath := path + "." + wildcard
+		b.register(wildcardPath, elem.Elem())
+		b.registerGoName(wildcardPath, joinPath(b.schema.goNames[path], wildcard))
+		// the values of a map are not addressable, so the fields under the wildcard can only be used by their paths
+		return b.addChildren(newStructSchema(elem.Elem()), elem.Elem(), wildcardPath, nil, 0, depth+1, append(stack, elem))
+	}
+
+	return nil
+}
+
+/*
+containerElem returns the element type behind the pointers, slices and arrays of t, it reports false for the recursive types such as type L []L.
+*/
+func containerElem(t reflect.Type) (reflect.Type, bool) {
+	seen := map[reflect.Type]bool{}
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		if seen[t] {
+			return nil, false
+		}
+		seen[t] = true
+		t = t.Elem()
+	}
+	return t, true
+}
+
+/*
+isExpanded reports whether the type is already being expanded, the recursive types are expanded again only if there is a depth limit.
+*/
+func (b *schemaBuilder) isExpanded(t reflect.Type, stack []reflect.Type) bool {
+	if b.options.maxDepth > 0 {
+		return false
+	}
+
+	for _, s := range stack {
+		if s == t {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *schemaBuilder) registerGoName(path string, goName string) {
+	b.schema.goNames[path] = goName
+	b.schema.goPaths[goName] = path
+}
+
+func joinPath(prefix string, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+func (b *schemaBuilder) register(path string, t reflect.Type) {
+	b.schema.names = append(b.schema.names, path)
+	b.schema.types[path] = t
+
+	if hasWildcard(path) {
+		b.schema.wildcards = append(b.schema.wildcards, path)
+	}
+}
+
+/*
+addMapKeys adds the key pattern of the map field that is declared with the kyte tag.
+
+	Attrs map[string]string `bson:"attrs" kyte:"keys=^[a-z_]+$"`
+*/
+func (b *schemaBuilder) addMapKeys(field reflect.StructField, path string) error {
+	tag, ok := field.Tag.Lookup("kyte")
+	if !ok {
+		return nil
+	}
+
+	pattern, ok := strings.CutPrefix(tag, "keys=")
+	if !ok {
+		return errors.Join(ErrInvalidStructTag, fmt.Errorf("field: %s unknown kyte tag: %s", field.Name, tag))
+	}
+
+	if elem, ok := containerElem(field.Type); !ok || elem.Kind() != reflect.Map {
+		return errors.Join(ErrInvalidStructTag, fmt.Errorf("field: %s key pattern is only valid for map fields", field.Name))
+	}
+
+	regex, err := regexp.Compile(pattern)
+	if err != nil {
+		return errors.Join(ErrInvalidStructTag, fmt.Errorf("field: %s %w", field.Name, err))
+	}
+
+	b.schema.mapKeys[path+"."+wildcard] = regex
+	return nil
+}
+
+/*
+documentFields returns the fields of the struct as the mongo driver encodes them, the fields of the inline structs are returned
+with the fields of the struct. A field of an inline struct is dominated by a field with the same name at a shallower level,
+the fields with the same name at the same level are reported as an error.
+*/
+func (b *schemaBuilder) documentFields(t reflect.Type) ([]documentField, bool, error) {
+	fields, inlineMap, err := b.collectFields(t, nil, []reflect.Type{t})
+	if err != nil {
+		return nil, false, err
+	}
+
+	sort.SliceStable(fields, func(i, j int) bool {
+		if fields[i].name != fields[j].name {
+			return fields[i].name < fields[j].name
+		}
+		return len(fields[i].index) < len(fields[j].index)
+	})
+
+	dominant := make([]documentField, 0, len(fields))
+	for i := 0; i < len(fields); i++ {
+		if i > 0 && fields[i].name == fields[i-1].name {
+			if len(fields[i].index) == len(dominant[len(dominant)-1].index) {
+				return nil, false, errors.Join(ErrDuplicateFieldName, fmt.Errorf("struct: %s field: %s", t, fields[i].name))
+			}
+			continue
+		}
+		dominant = append(dominant, fields[i])
+	}
+
+	sort.SliceStable(dominant, func(i, j int) bool {
+		return lessIndex(dominant[i].index, dominant[j].index)
+	})
+
+	return dominant, inlineMap, nil
+}
+
+/*
+collectFields returns the encoded fields of the struct and of its inline structs, and whether the struct has an inline map.
+*/
+func (b *schemaBuilder) collectFields(t reflect.Type, index []int, inlined []reflect.Type) ([]documentField, bool, error) {
+	var fields []documentField
+	inlineMap := false
+	inlineMaps := 0
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tags, ok, err := parseStructTags(b.options.parser, field)
+		if err != nil {
+			return nil, false, err
+		}
+
+		if !ok {
+			continue
+		}
+
+		fieldIndex := append(append([]int{}, index...), i)
+		if !tags.Inline {
+			fields = append(fields, documentField{name: tags.Name, field: field, index: fieldIndex})
+			continue
+		}
+
+		inlineType := field.Type
+		if inlineType.Kind() == reflect.Ptr && inlineType.Elem().Kind() == reflect.Struct {
+			inlineType = inlineType.Elem()
+		}
+
+		switch {
+		case inlineType.Kind() == reflect.Struct:
+			for _, s := range inlined {
+				if s == inlineType {
+					return nil, false, errors.Join(ErrInvalidInlineField, fmt.Errorf("struct: %s inlines itself", inlineType))
+				}
+			}
+
+			inlineFields, hasMap, err := b.collectFields(inlineType, fieldIndex, append(inlined, inlineType))
+			if err != nil {
+				return nil, false, err
+			}
+			fields = append(fields, inlineFields...)
+			inlineMap = inlineMap || hasMap
+		case inlineType.Kind() == reflect.Map && inlineType.Key().Kind() == reflect.String:
+			inlineMaps++
+			if inlineMaps > 1 {
+				return nil, false, errors.Join(ErrInvalidInlineField, fmt.Errorf("struct: %s has multiple inline maps", t))
+			}
+			inlineMap = true
+		default:
+			return nil, false, errors.Join(ErrInvalidInlineField, fmt.Errorf("struct: %s field: %s", t, field.Name))
+		}
+	}
+
+	return fields, inlineMap, nil
+}
+
+func lessIndex(a, b []int) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return len(a) < len(b)
+}
+
+func (s *structSchema) add(f schemaField) {
+	s.offsets[fieldKey{offset: f.offset, typ: f.typ}] = len(s.fields)
+	s.paths[f.path] = len(s.fields)
+	s.fields = append(s.fields, f)
+}
+
+/*
+indirectSchema returns the schema of the pointed struct or the slice element at the given index of the memory block.
+*/
+func (s *structSchema) indirectSchema(index []int, t reflect.Type) *structSchema {
+	for _, ind := range s.indirect {
+		if reflect.DeepEqual(ind.index, index) {
+			return ind.elem
+		}
+	}
+
+	elem := newStructSchema(t)
+	s.indirect = append(s.indirect, indirectField{index: append([]int{}, index...), elem: elem})
+	return elem
+}
+
+/*
+lookup returns the path of the field at the given address, the struct of the schema starts at the address of v.
+*/
+func (s *structSchema) lookup(v reflect.Value, addr uintptr, t reflect.Type) (string, bool) {
+	start := v.UnsafeAddr()
+	if addr >= start && addr-start < s.typ.Size() {
+		if i, ok := s.offsets[fieldKey{offset: addr - start, typ: t}]; ok {
+			return s.fields[i].path, true
+		}
+	}
+
+	for _, ind := range s.indirect {
+		if path, ok := ind.lookup(v.FieldByIndex(ind.index), addr, t); ok {
+			return path, true
+		}
+	}
+
+	return "", false
+}
+
+/*
+value returns the value of the field at the given path, the fields of a nil pointer or an empty slice are returned from a new value of the struct.
+*/
+func (s *structSchema) value(v reflect.Value, path string) (reflect.Value, bool) {
+	if i, ok := s.paths[path]; ok {
+		return v.FieldByIndex(s.fields[i].index), true
+	}
+
+	for _, ind := range s.indirect {
+		elem, ok := ind.elemValue(v)
+		if !ok {
+			elem = reflect.New(ind.elem.typ).Elem()
+		}
+
+		if value, ok := ind.elem.value(elem, path); ok {
+			return value, true
+		}
+	}
+
+	return reflect.Value{}, false
+}
+
+/*
+elemValue returns the pointed struct or the first element of the slice or the array of the instance.
+*/
+func (f indirectField) elemValue(base reflect.Value) (reflect.Value, bool) {
+	v := base.FieldByIndex(f.index)
+	for {
+		switch v.Kind() {
+		case reflect.Ptr:
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		case reflect.Slice, reflect.Array:
+			if v.Len() == 0 {
+				return reflect.Value{}, false
+			}
+			v = v.Index(0)
+		default:
+			return v, v.Kind() == reflect.Struct && v.CanAddr()
+		}
+	}
+}
+
+/*
+lookup returns the path of the field at the given address in the pointed struct or in the elements of the instance.
+The element that holds the address is searched first, the other elements are searched for the fields behind their pointers.
+*/
+func (f indirectField) lookup(v reflect.Value, addr uintptr, t reflect.Type) (string, bool) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return "", false
+		}
+		return f.lookup(v.Elem(), addr, t)
+	case reflect.Slice, reflect.Array:
+		if i, ok := elemIndex(v, addr); ok {
+			return f.lookup(v.Index(i), addr, t)
+		}
+
+		for i := 0; i < v.Len(); i++ {
+			if path, ok := f.lookup(v.Index(i), addr, t); ok {
+				return path, true
+			}
+		}
+		return "", false
+	case reflect.Struct:
+		if !v.CanAddr() {
+			return "", false
+		}
+		return f.elem.lookup(v, addr, t)
+	}
+
+	return "", false
+}
+
+/*
+elemIndex returns the index of the element of the slice or the array that holds the given address.
+*/
+func elemIndex(v reflect.Value, addr uintptr) (int, bool) {
+	if v.Len() == 0 {
+		return 0, false
+	}
+
+	first := v.Index(0)
+	if first.Kind() == reflect.Ptr {
+		size := first.Type().Elem().Size()
+		for i := 0; i < v.Len(); i++ {
+			if p := v.Index(i).Pointer(); p != 0 && addr >= p && addr-p < size {
+				return i, true
+			}
+		}
+		return 0, false
+	}
+
+	size := first.Type().Size()
+	if !first.CanAddr() || size == 0 {
+		return 0, false
+	}
+
+	start := first.UnsafeAddr()
+	if addr >= start && addr-start < size*uintptr(v.Len()) {
+		return int((addr - start) / size), true
+	}
+	return 0, false
+}
+
+/*
+inInlineMap reports whether the path is a key of a document that has an inline map.
+*/
+func (s *typeSchema) inInlineMap(path string) bool {
+	for _, prefix := range s.inlineMaps {
+		if strings.HasPrefix(path, prefix) && len(path) > len(prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+lookupField returns the path of the given pointer field of the source instance.
+*/
+func (k *kyte) lookupField(field any) (string, bool) {
+	if k.schema == nil {
+		return "", false
+	}
+
+	v := reflect.ValueOf(field)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return "", false
+	}
+
+	return k.schema.lookup(k.base, v.Pointer(), v.Type().Elem())
+}
+
+/*
+valueOf returns the value of the field at the given path of the source instance. The fields of a nil pointer or an empty slice
+are returned from a new value of the struct.
+*/
+func (k *kyte) valueOf(path string) (reflect.Value, bool) {
+	if k.schema == nil {
+		return reflect.Value{}, false
+	}
+
+	return k.schema.value(k.base, path)
+}
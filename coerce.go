@@ -0,0 +1,97 @@
+package kyte
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var objectIDType = reflect.TypeOf(primitive.ObjectID{})
+
+// timeLayouts are the layouts that are accepted for time.Time and primitive.DateTime fields.
+var timeLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02"}
+
+/*
+coerceValue converts the raw text of the literal to the type of the source field at the given path, the typed value of the literal
+is returned if the field is not in the source or the literal is null. The elements of the slice fields are converted to the element type.
+*/
+func (k *kyte) coerceValue(path string, value literal) (any, error) {
+	t, ok := k.fieldType(path)
+	if !ok || value.value == nil {
+		return value.value, nil
+	}
+
+	t = indirectType(t)
+	if isArrayType(t) && t != objectIDType {
+		t = indirectType(t.Elem())
+	}
+
+	coerced, err := coerceString(t, value.raw)
+	if err != nil {
+		return nil, errors.Join(ErrInvalidParamValue, fmt.Errorf("field: %s expected: %s given: %q", path, t, value.raw))
+	}
+	return coerced, nil
+}
+
+func coerceString(t reflect.Type, raw string) (any, error) {
+	switch t {
+	case objectIDType:
+		return primitive.ObjectIDFromHex(raw)
+	case timeType:
+		return parseTime(raw)
+	case dateTimeType:
+		parsed, err := parseTime(raw)
+		if err != nil {
+			return nil, err
+		}
+		return primitive.NewDateTimeFromTime(parsed), nil
+	case decimalType:
+		return primitive.ParseDecimal128(raw)
+	}
+
+	var value any
+	var err error
+	switch t.Kind() {
+	case reflect.String:
+		value = reflect.ValueOf(raw).Convert(t).Interface()
+	case reflect.Bool:
+		var b bool
+		b, err = strconv.ParseBool(raw)
+		value = reflect.ValueOf(b).Convert(t).Interface()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var i int64
+		i, err = strconv.ParseInt(raw, 10, t.Bits())
+		value = reflect.ValueOf(i).Convert(t).Interface()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var u uint64
+		u, err = strconv.ParseUint(raw, 10, t.Bits())
+		value = reflect.ValueOf(u).Convert(t).Interface()
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		f, err = strconv.ParseFloat(raw, t.Bits())
+		value = reflect.ValueOf(f).Convert(t).Interface()
+	case reflect.Interface:
+		value = raw
+	default:
+		err = fmt.Errorf("type %s cannot be parsed from a string", t)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func parseTime(raw string) (time.Time, error) {
+	for _, layout := range timeLayouts {
+		if parsed, err := time.Parse(layout, raw); err == nil {
+			return parsed, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("time must be in one of the layouts: %s", strings.Join(timeLayouts, ", "))
+}
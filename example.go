@@ -0,0 +1,269 @@
+package kyte
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+var (
+	marshalerType      = reflect.TypeOf((*bson.Marshaler)(nil)).Elem()
+	valueMarshalerType = reflect.TypeOf((*bson.ValueMarshaler)(nil)).Elem()
+)
+
+/*
+ZeroFields is an option function of [FromExample], the zero values of the given fields are matched instead of being skipped.
+The fields can be strings or pointers of the example struct fields.
+
+	kyte.FromExample(&user, kyte.ZeroFields(&user.Age)) // {"age": {"$eq": 0}}
+*/
+func ZeroFields(fields ...any) OptionFunc {
+	return func(o *Options) {
+		o.zeroFields = append(o.zeroFields, fields...)
+		o.scoped |= zeroFieldsOption
+	}
+}
+
+/*
+SliceAsIn is an option function of [FromExample], the slice fields are matched with $in so a document matches if it has any of the values.
+Otherwise the slice fields are matched with the whole array.
+
+	kyte.FromExample(&User{Tags: []string{"go", "mongo"}}, kyte.SliceAsIn(true)) // {"tags": {"$in": ["go", "mongo"]}}
+*/
+func SliceAsIn(sliceAsIn bool) OptionFunc {
+	return func(o *Options) {
+		o.sliceAsIn = sliceAsIn
+		o.scoped |= sliceAsInOption
+	}
+}
+
+/*
+IgnoreCase is an option function of [FromExample], the string fields are matched case insensitively with an anchored $regex.
+
+	kyte.FromExample(&User{Name: "john"}, kyte.IgnoreCase(true)) // {"name": {"$regex": "^john$", "$options": "i"}}
+*/
+func IgnoreCase(ignoreCase bool) OptionFunc {
+	return func(o *Options) {
+		o.ignoreCase = ignoreCase
+		o.scoped |= ignoreCaseOption
+	}
+}
+
+/*
+FromExample creates a filter that matches the documents like the given example, it is a pointer of a partially populated struct.
+Each non zero field is an equality condition on its bson path, the nested structs and the string keyed maps are matched by their
+dotted paths. The example is the source of the filter, so the fields are discovered with the same rules and options as [Source],
+a nil example returns [ErrNilSource].
+
+	kyte.FromExample(&User{Name: "John", Address: Address{City: "Izmir"}})
+	// {"name": {"$eq": "John"}, "address.city": {"$eq": "Izmir"}}
+
+Use [ZeroFields] to match the zero values of some fields, [SliceAsIn] to match the slice fields with $in and [IgnoreCase]
+to match the string fields case insensitively. A nil pointer is a zero value, the zero fields that are nil pointers are matched with null.
+The empty slices and maps are skipped like the nil ones, a form binder creates them for the blank fields, unless they are zero fields.
+The fields of the recursive types are valid only until the [MaxDepth], the pointer cycles of the example are not followed.
+*/
+func FromExample(example any, opts ...OptionFunc) (*FilterBuilder, error) {
+	if v := reflect.ValueOf(example); example == nil || v.Kind() == reflect.Ptr && v.IsNil() {
+		return nil, ErrNilSource
+	}
+
+	options := newOptions(append([]OptionFunc{Source(example)}, opts...))
+	if err := options.unsupported(zeroFieldsOption | sliceAsInOption | ignoreCaseOption); err != nil {
+		return nil, err
+	}
+
+	f := newFilter(options)
+	if f.kyte.hasErrors() {
+		return nil, f.kyte.err
+	}
+
+	e := &exampleBuilder{
+		filter:  f,
+		options: options,
+		visited: map[uintptr]bool{reflect.ValueOf(example).Pointer(): true},
+	}
+
+	for _, field := range options.zeroFields {
+		path, err := f.kyte.resolveField(field)
+		if err != nil {
+			return nil, err
+		}
+		e.zeroPaths = append(e.zeroPaths, path)
+	}
+
+	if err := e.addDocument(f.kyte.base, ""); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+/*
+exampleBuilder adds the conditions of the example fields to the filter, visited holds the structs behind the pointers of the current path.
+*/
+type exampleBuilder struct {
+	filter    *FilterBuilder
+	options   *Options
+	zeroPaths []string
+	visited   map[uintptr]bool
+}
+
+func (e *exampleBuilder) addDocument(v reflect.Value, prefix string) error {
+	fields, err := e.documentFields(v.Type())
+	if err != nil {
+		return err
+	}
+
+	for _, df := range fields {
+		fv, err := v.FieldByIndexErr(df.index)
+		if err != nil {
+			// the field is behind a nil inline pointer
+			continue
+		}
+
+		if err := e.addValue(fv, prefix+df.name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+/*
+documentFields returns the cached document fields of the struct type, the structs behind the interface fields are not
+a part of the source schema so their fields are taken from their own cached schema.
+*/
+func (e *exampleBuilder) documentFields(t reflect.Type) ([]documentField, error) {
+	if fields, ok := e.filter.kyte.schema.documents[t]; ok {
+		return fields, nil
+	}
+
+	schema, err := getSchema(t, e.filter.kyte.options)
+	if err != nil {
+		return nil, err
+	}
+	return schema.documents[t], nil
+}
+
+func (e *exampleBuilder) addValue(v reflect.Value, path string) error {
+	zero, nested := e.zeroField(path)
+	if v.IsZero() && !zero && !nested {
+		return nil
+	}
+
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			switch {
+			case zero:
+				e.filter.Equal(path, nil)
+			case nested && v.Kind() == reflect.Ptr:
+				// the zero fields under a nil pointer are matched with the zero values of the pointed struct
+				v = reflect.New(v.Type().Elem())
+				continue
+			}
+			return nil
+		}
+
+		if v.Kind() == reflect.Ptr && v.Elem().Kind() == reflect.Struct {
+			if e.visited[v.Pointer()] {
+				return nil
+			}
+			e.visited[v.Pointer()] = true
+			defer delete(e.visited, v.Pointer())
+		}
+		v = v.Elem()
+	}
+
+	// an empty slice or map is not the zero value, but like a nil one it is usually a blank form field
+	if (v.Kind() == reflect.Slice || v.Kind() == reflect.Map) && v.Len() == 0 && !zero {
+		return nil
+	}
+
+	switch {
+	case isExampleLeaf(v.Type()):
+		e.filter.Equal(path, v.Interface())
+	case v.Kind() == reflect.Struct:
+		if zero && v.IsZero() {
+			e.filter.Equal(path, v.Interface())
+			return nil
+		}
+		return e.addDocument(v, path+".")
+	case v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String:
+		if zero && v.Len() == 0 {
+			e.filter.Equal(path, v.Interface())
+			return nil
+		}
+		return e.addMap(v, path)
+	case v.Kind() == reflect.Slice || v.Kind() == reflect.Array:
+		if e.options.sliceAsIn && v.Len() > 0 {
+			e.filter.In(path, v.Interface())
+		} else {
+			e.filter.Equal(path, v.Interface())
+		}
+	case v.Kind() == reflect.String && e.options.ignoreCase:
+		e.filter.Regex(path, regexp.MustCompile("^"+regexp.QuoteMeta(v.String())+"$"), "i")
+	default:
+		e.filter.Equal(path, v.Interface())
+	}
+
+	return nil
+}
+
+/*
+zeroField reports whether the zero value of the path is matched and whether there is a zero field under the path.
+*/
+func (e *exampleBuilder) zeroField(path string) (bool, bool) {
+	zero, nested := false, false
+	for _, p := range e.zeroPaths {
+		zero = zero || p == path
+		nested = nested || strings.HasPrefix(p, path+".")
+	}
+	return zero, nested
+}
+
+/*
+addMap adds the values of the map by their dotted paths, the keys are sorted to keep the order of the conditions stable.
+*/
+func (e *exampleBuilder) addMap(v reflect.Value, path string) error {
+	keys := make([]string, 0, v.Len())
+	for _, key := range v.MapKeys() {
+		keys = append(keys, key.String())
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if key == "" || strings.ContainsAny(key, ".$") {
+			return errors.Join(ErrInvalidMapKey, fmt.Errorf("field: %s key: %q", path, key))
+		}
+
+		value := v.MapIndex(reflect.ValueOf(key).Convert(v.Type().Key()))
+		if err := e.addValue(value, path+"."+key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+/*
+isExampleLeaf reports whether the values of the type are matched as a whole, such as time.Time, the bson types and the types that marshal themselves.
+*/
+func isExampleLeaf(t reflect.Type) bool {
+	switch t {
+	case timeType, dateTimeType, decimalType, objectIDType:
+		return true
+	}
+
+	if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+		return true
+	}
+
+	return t.Implements(marshalerType) || t.Implements(valueMarshalerType) ||
+		reflect.PtrTo(t).Implements(marshalerType) || reflect.PtrTo(t).Implements(valueMarshalerType)
+}
@@ -0,0 +1,179 @@
+package kyte_test
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/aaydin-tr/kyte"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestFromExample(t *testing.T) {
+	t.Parallel()
+
+	joined := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	id := primitive.NewObjectID()
+
+	tests := []struct {
+		name    string
+		example func() (*TestUser, []kyte.OptionFunc)
+		target  bson.D
+	}{
+		{
+			name: "non zero fields",
+			example: func() (*TestUser, []kyte.OptionFunc) {
+				return &TestUser{
+					ID:        id,
+					Name:      "John",
+					Tags:      []string{"go", "mongo"},
+					Address:   TestAddress{City: "Izmir"},
+					Manager:   &TestUser{Name: "Jane"},
+					Labels:    map[string]string{"team": "core", "empty": ""},
+					CreatedAt: joined,
+					password:  "secret",
+					Nickname:  "Johnny",
+				}, []kyte.OptionFunc{kyte.MaxDepth(2)}
+			},
+			target: bson.D{
+				{Key: "_id", Value: bson.M{"$eq": id}},
+				{Key: "name", Value: bson.M{"$eq": "John"}},
+				{Key: "tags", Value: bson.M{"$eq": []string{"go", "mongo"}}},
+				{Key: "address.city", Value: bson.M{"$eq": "Izmir"}},
+				{Key: "manager.name", Value: bson.M{"$eq": "Jane"}},
+				{Key: "labels.team", Value: bson.M{"$eq": "core"}},
+				{Key: "created_at", Value: bson.M{"$eq": joined}},
+			},
+		},
+		{
+			name: "zero fields",
+			example: func() (*TestUser, []kyte.OptionFunc) {
+				user := &TestUser{Name: "John"}
+				return user, []kyte.OptionFunc{kyte.MaxDepth(2), kyte.ZeroFields(&user.Age, &user.Active, "address.zip_code", "deleted_at", "manager.age")}
+			},
+			target: bson.D{
+				{Key: "name", Value: bson.M{"$eq": "John"}},
+				{Key: "age", Value: bson.M{"$eq": 0}},
+				{Key: "active", Value: bson.M{"$eq": false}},
+				{Key: "address.zip_code", Value: bson.M{"$eq": ""}},
+				{Key: "manager.age", Value: bson.M{"$eq": 0}},
+				{Key: "deleted_at", Value: bson.M{"$eq": nil}},
+			},
+		},
+		{
+			name: "slices with in and case insensitive strings",
+			example: func() (*TestUser, []kyte.OptionFunc) {
+				return &TestUser{Name: "j.doe", Tags: []string{"go"}, Address: TestAddress{City: "izmir"}}, []kyte.OptionFunc{kyte.SliceAsIn(true), kyte.IgnoreCase(true)}
+			},
+			target: bson.D{
+				{Key: "name", Value: bson.M{"$regex": `^j\.doe$`, "$options": "i"}},
+				{Key: "tags", Value: bson.M{"$in": []string{"go"}}},
+				{Key: "address.city", Value: bson.M{"$regex": "^izmir$", "$options": "i"}},
+			},
+		},
+		{
+			name: "empty slices and maps",
+			example: func() (*TestUser, []kyte.OptionFunc) {
+				return &TestUser{Name: "John", Tags: []string{}, Labels: map[string]string{}}, nil
+			},
+			target: bson.D{
+				{Key: "name", Value: bson.M{"$eq": "John"}},
+			},
+		},
+		{
+			name: "empty zero slices",
+			example: func() (*TestUser, []kyte.OptionFunc) {
+				user := &TestUser{Name: "John", Tags: []string{}}
+				return user, []kyte.OptionFunc{kyte.ZeroFields(&user.Tags)}
+			},
+			target: bson.D{
+				{Key: "name", Value: bson.M{"$eq": "John"}},
+				{Key: "tags", Value: bson.M{"$eq": []string{}}},
+			},
+		},
+		{
+			name: "cyclic example",
+			example: func() (*TestUser, []kyte.OptionFunc) {
+				user := &TestUser{Name: "John"}
+				user.Manager = user
+				return user, []kyte.OptionFunc{kyte.MaxDepth(2)}
+			},
+			target: bson.D{
+				{Key: "name", Value: bson.M{"$eq": "John"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			example, opts := tt.example()
+			f, err := kyte.FromExample(example, opts...)
+			testParsedFilter(t, "FromExample", f, err, tt.target)
+		})
+	}
+
+	t.Run("interface fields", func(t *testing.T) {
+		type Contact struct {
+			Name  string `bson:"name"`
+			Extra any    `bson:"extra"`
+		}
+
+		f, err := kyte.FromExample(&Contact{Extra: TestAddress{City: "Izmir"}}, kyte.ValidateField(false))
+
+		target := bson.D{{Key: "extra.city", Value: bson.M{"$eq": "Izmir"}}}
+		testParsedFilter(t, "FromExample", f, err, target)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		if _, err := kyte.FromExample(nil); !errors.Is(err, kyte.ErrNilSource) {
+			t.Errorf("FromExample should return error %v, got %v", kyte.ErrNilSource, err)
+		}
+
+		if _, err := kyte.FromExample((*TestUser)(nil)); !errors.Is(err, kyte.ErrNilSource) {
+			t.Errorf("FromExample should return error %v, got %v", kyte.ErrNilSource, err)
+		}
+
+		if _, err := kyte.FromExample(TestUser{}); !errors.Is(err, kyte.ErrNotPtrSource) {
+			t.Errorf("FromExample should return error %v, got %v", kyte.ErrNotPtrSource, err)
+		}
+
+		if _, err := kyte.FromExample(new(string)); !errors.Is(err, kyte.ErrNotStruct) {
+			t.Errorf("FromExample should return error %v, got %v", kyte.ErrNotStruct, err)
+		}
+
+		if _, err := kyte.FromExample(&TestUser{Name: "John"}, kyte.AllowFields("name")); !errors.Is(err, kyte.ErrUnsupportedOption) {
+			t.Errorf("FromExample should return error %v, got %v", kyte.ErrUnsupportedOption, err)
+		}
+
+		if _, err := kyte.Filter(kyte.IgnoreCase(true)).Equal("name", "John").Build(); !errors.Is(err, kyte.ErrUnsupportedOption) {
+			t.Errorf("Filter.Build should return error %v, got %v", kyte.ErrUnsupportedOption, err)
+		}
+
+		if _, err := kyte.Update(kyte.SliceAsIn(true)).Set("name", "John").Build(); !errors.Is(err, kyte.ErrUnsupportedOption) {
+			t.Errorf("Update.Build should return error %v, got %v", kyte.ErrUnsupportedOption, err)
+		}
+
+		if _, err := kyte.FromURLValues(url.Values{"name": {"John"}}, kyte.ZeroFields("age")); !errors.Is(err, kyte.ErrUnsupportedOption) {
+			t.Errorf("FromURLValues should return error %v, got %v", kyte.ErrUnsupportedOption, err)
+		}
+
+		if _, err := kyte.FromExample(&TestUser{}, kyte.ZeroFields("password")); !errors.Is(err, kyte.ErrNotValidFieldForQuery) {
+			t.Errorf("FromExample should return error %v, got %v", kyte.ErrNotValidFieldForQuery, err)
+		}
+
+		f, err := kyte.FromExample(&TestUser{Manager: &TestUser{Name: "Jane"}})
+		if err != nil {
+			t.Fatalf("FromExample should not return error: %v", err)
+		}
+		if _, err := f.Build(); !errors.Is(err, kyte.ErrNotValidFieldForQuery) {
+			t.Errorf("FromExample should return error %v, got %v", kyte.ErrNotValidFieldForQuery, err)
+		}
+
+		if _, err := kyte.FromExample(&TestUser{Labels: map[string]string{"a.b": "c"}}); !errors.Is(err, kyte.ErrInvalidMapKey) {
+			t.Errorf("FromExample should return error %v, got %v", kyte.ErrInvalidMapKey, err)
+		}
+	})
+}
@@ -0,0 +1,422 @@
+package kyte
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxExpressionDepth is the maximum nesting depth of the parsed expressions, the parsers are recursive and the queries can come from the end users.
+const maxExpressionDepth = 64
+
+/*
+SyntaxError is returned by the query language parsers such as [FromRSQL], Offset is the byte offset of the invalid token in the query.
+It wraps [ErrInvalidSyntax].
+*/
+type SyntaxError struct {
+	Offset int
+	Msg    string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("syntax error at offset %d: %s", e.Offset, e.Msg)
+}
+
+func (e *SyntaxError) Unwrap() error {
+	return ErrInvalidSyntax
+}
+
+func nestingError(offset int) *SyntaxError {
+	return &SyntaxError{Offset: offset, Msg: fmt.Sprintf("expression is nested deeper than %d levels", maxExpressionDepth)}
+}
+
+/*
+UnsupportedError is returned by the query language parsers for a valid construct of the language that cannot be translated to a filter,
+Construct describes it. It wraps [ErrUnsupportedExpression].
+*/
+type UnsupportedError struct {
+	Offset    int
+	Construct string
+}
+
+func (e *UnsupportedError) Error() string {
+	return fmt.Sprintf("unsupported expression at offset %d: %s", e.Offset, e.Construct)
+}
+
+func (e *UnsupportedError) Unwrap() error {
+	return ErrUnsupportedExpression
+}
+
+/*
+expression is a node of the parsed query, the query languages are parsed into expressions and then applied to a filter.
+*/
+type expression interface {
+	offset() int
+}
+
+/*
+logicalExpr combines its operands with the $and or $or operator.
+*/
+type logicalExpr struct {
+	pos      int
+	operator string
+	operands []expression
+}
+
+func (e *logicalExpr) offset() int {
+	return e.pos
+}
+
+/*
+comparisonExpr compares the field with the values, the values are converted to the type of the field when it is applied.
+The negated comparison is wrapped with $not and the options are the options of $regex.
+*/
+type comparisonExpr struct {
+	pos      int
+	field    string
+	operator string
+	values   []literal
+	negate   bool
+	options  string
+}
+
+func (e *comparisonExpr) offset() int {
+	return e.pos
+}
+
+/*
+parseLogical parses the operands that are separated by the logical operator, the nested operands of the same operator are flattened.
+*/
+func parseLogical(operator string, pos int, operand func() (expression, error), separator func() bool) (expression, error) {
+	first, err := operand()
+	if err != nil {
+		return nil, err
+	}
+
+	operands := []expression{first}
+	for separator() {
+		next, err := operand()
+		if err != nil {
+			return nil, err
+		}
+
+		if e, ok := next.(*logicalExpr); ok && e.operator == operator {
+			operands = append(operands, e.operands...)
+		} else {
+			operands = append(operands, next)
+		}
+	}
+
+	if len(operands) == 1 {
+		return first, nil
+	}
+	return &logicalExpr{pos: pos, operator: operator, operands: operands}, nil
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenOperator
+	tokenOpen
+	tokenClose
+	tokenComma
+	tokenOther
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+/*
+tokenStream is the list of the tokens of a query that ends with a tokenEOF, the keywords are case insensitive if foldCase is set.
+*/
+type tokenStream struct {
+	tokens   []token
+	pos      int
+	foldCase bool
+	depth    int
+}
+
+func (s *tokenStream) add(kind tokenKind, text string, pos int) {
+	s.tokens = append(s.tokens, token{kind: kind, text: text, pos: pos})
+}
+
+func (s *tokenStream) peek() token {
+	return s.tokens[s.pos]
+}
+
+func (s *tokenStream) peekAt(n int) token {
+	if s.pos+n >= len(s.tokens) {
+		return s.tokens[len(s.tokens)-1]
+	}
+	return s.tokens[s.pos+n]
+}
+
+func (s *tokenStream) next() token {
+	t := s.tokens[s.pos]
+	if t.kind != tokenEOF {
+		s.pos++
+	}
+	return t
+}
+
+func (s *tokenStream) isKeyword(t token, word string) bool {
+	if t.kind != tokenIdent {
+		return false
+	}
+	if s.foldCase {
+		return strings.EqualFold(t.text, word)
+	}
+	return t.text == word
+}
+
+func (s *tokenStream) keyword(word string) bool {
+	if s.isKeyword(s.peek(), word) {
+		s.next()
+		return true
+	}
+	return false
+}
+
+/*
+enter increases the nesting depth for the group or the unary operator at the given token, leave decreases it.
+*/
+func (s *tokenStream) enter(t token) error {
+	if s.depth == maxExpressionDepth {
+		return nestingError(t.pos)
+	}
+	s.depth++
+	return nil
+}
+
+func (s *tokenStream) leave() {
+	s.depth--
+}
+
+func (s *tokenStream) expect(kind tokenKind, name string) error {
+	if t := s.next(); t.kind != kind {
+		return expectedError(t, name)
+	}
+	return nil
+}
+
+/*
+scanQuoted scans the quoted text that starts at the given index, a doubled quote is an escaped quote. It returns the unquoted text
+and the index after the closing quote, and reports false if the text is not terminated.
+*/
+func scanQuoted(input string, start int) (string, int, bool) {
+	quote := input[start]
+	var sb strings.Builder
+	for i := start + 1; i < len(input); i++ {
+		if input[i] != quote {
+			sb.WriteByte(input[i])
+			continue
+		}
+
+		if i+1 < len(input) && input[i+1] == quote {
+			sb.WriteByte(quote)
+			i++
+			continue
+		}
+		return sb.String(), i + 1, true
+	}
+	return "", len(input), false
+}
+
+func expectedError(t token, name string) *SyntaxError {
+	if t.kind == tokenEOF {
+		return &SyntaxError{Offset: t.pos, Msg: fmt.Sprintf("expected %s at the end of the query", name)}
+	}
+	return &SyntaxError{Offset: t.pos, Msg: fmt.Sprintf("expected %s, found %q", name, t.text)}
+}
+
+func unexpectedError(t token) *SyntaxError {
+	if t.kind == tokenEOF {
+		return &SyntaxError{Offset: t.pos, Msg: "unexpected end of the query"}
+	}
+	return &SyntaxError{Offset: t.pos, Msg: fmt.Sprintf("unexpected %q", t.text)}
+}
+
+/*
+literal is a value of the query, raw is its text and value is its typed value that is used when the field is not in the source.
+The value of null is nil.
+*/
+type literal struct {
+	raw   string
+	value any
+}
+
+func rawLiterals(raws []string) []literal {
+	literals := make([]literal, 0, len(raws))
+	for _, raw := range raws {
+		literals = append(literals, literal{raw: raw, value: raw})
+	}
+	return literals
+}
+
+/*
+negateExpr negates the expression, a comparison is wrapped with $not and a logical expression is wrapped with $nor.
+*/
+func negateExpr(expr expression) expression {
+	if e, ok := expr.(*comparisonExpr); ok {
+		negated := *e
+		negated.negate = !e.negate
+		return &negated
+	}
+	return &logicalExpr{pos: expr.offset(), operator: nor, operands: []expression{expr}}
+}
+
+/*
+expressionBuilder applies the parsed expressions to filters, the nested filters are created with the same options.
+*/
+type expressionBuilder struct {
+	opts    []OptionFunc
+	allowed *allowlist
+}
+
+func newExpressionBuilder(opts []OptionFunc) (*expressionBuilder, *FilterBuilder, error) {
+	options := newOptions(opts)
+	if err := options.unsupported(allowOperatorsOption | allowFieldsOption); err != nil {
+		return nil, nil, err
+	}
+
+	f := newFilter(options)
+	allowed, err := newAllowlist(f.kyte, options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// the nested filters are a part of the query, only the top level filter is optimized
+	nested := append(opts[:len(opts):len(opts)], func(o *Options) {
+		o.optimize = false
+	})
+	return &expressionBuilder{opts: nested, allowed: allowed}, f, nil
+}
+
+/*
+apply adds the expression to the top level of the filter, the operands of $and are flattened and $or is added as a nested filter.
+*/
+func (b *expressionBuilder) apply(f *FilterBuilder, expr expression) error {
+	switch e := expr.(type) {
+	case *comparisonExpr:
+		return b.compare(f, e)
+	case *logicalExpr:
+		if e.operator == and {
+			for _, operand := range e.operands {
+				if err := b.apply(f, operand); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		sub := newFilter(newOptions(b.opts))
+		for _, operand := range e.operands {
+			if err := b.applyOperand(sub, operand); err != nil {
+				return err
+			}
+		}
+		f.logical(e.operator, sub)
+		return nil
+	}
+
+	return fmt.Errorf("unknown expression %T", expr)
+}
+
+/*
+applyOperand adds the operand of a logical expression as a single item, an operand with more than one condition is wrapped with $and.
+*/
+func (b *expressionBuilder) applyOperand(f *FilterBuilder, expr expression) error {
+	if e, ok := expr.(*logicalExpr); ok && e.operator == and {
+		sub := newFilter(newOptions(b.opts))
+		if err := b.apply(sub, e); err != nil {
+			return err
+		}
+		f.And(sub)
+		return nil
+	}
+
+	return b.apply(f, expr)
+}
+
+func (b *expressionBuilder) compare(f *FilterBuilder, e *comparisonExpr) error {
+	if err := b.allowed.operator(e.operator); err != nil {
+		return errors.Join(err, fmt.Errorf("offset: %d", e.pos))
+	}
+
+	path, err := b.allowed.field(e.field)
+	if err != nil {
+		return errors.Join(err, fmt.Errorf("offset: %d", e.pos))
+	}
+
+	if e.negate {
+		f.Not()
+	}
+
+	var options []string
+	if e.options != "" {
+		options = append(options, e.options)
+	}
+
+	if err := applyCondition(f, e.operator, path, e.values, options...); err != nil {
+		return errors.Join(err, fmt.Errorf("offset: %d", e.pos))
+	}
+	return nil
+}
+
+/*
+applyCondition adds the condition of the operator with the values that are converted to the type of the field.
+The in and nin operators take a list of values, the other operators take a single value. The options are the options of $regex.
+*/
+func applyCondition(f *FilterBuilder, operator string, path string, values []literal, options ...string) error {
+	if operator == in || operator == nin {
+		items := []any{}
+		for _, value := range values {
+			item, err := f.kyte.coerceValue(path, value)
+			if err != nil {
+				return err
+			}
+			items = append(items, item)
+		}
+
+		f.set(operator, path, items, true)
+		return nil
+	}
+
+	if len(values) != 1 {
+		return errors.Join(ErrInvalidParamValue, fmt.Errorf("operator: %s must have a single value, given: %d", operator, len(values)))
+	}
+
+	switch operator {
+	case regx:
+		regex, err := regexp.Compile(values[0].raw)
+		if err != nil {
+			return errors.Join(ErrInvalidParamValue, err)
+		}
+		f.Regex(path, regex, options...)
+		return nil
+	case exists:
+		value, err := strconv.ParseBool(values[0].raw)
+		if err != nil {
+			return errors.Join(ErrInvalidParamValue, err)
+		}
+		f.Exists(path, value)
+		return nil
+	}
+
+	value, err := f.kyte.coerceValue(path, values[0])
+	if err != nil {
+		return err
+	}
+
+	f.set(operator, path, value, true)
+	return nil
+}
@@ -3,6 +3,7 @@ package kyte
 import (
 	"reflect"
 	"regexp"
+	"sort"
 	"sync"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -10,7 +11,7 @@ import (
 )
 
 var (
-	globalFilters []*filter
+	globalFilters []Condition
 	globalMutex   sync.RWMutex
 )
 
@@ -38,10 +39,8 @@ const (
 	all        = "$all"
 	size       = "$size"
 	jsonSchema = "$jsonSchema"
-
-	// TODO implement Day 1
-	// $elemMatch
-	// $not
+	elemMatch  = "$elemMatch"
+	not        = "$not"
 
 	// TODO: implement Later
 	// $text
@@ -66,7 +65,7 @@ Example:
 	// Set up a global tenant filter
 	kyte.AddGlobalFilter(kyte.Filter().Equal("tenantId", "123"))
 */
-func AddGlobalFilter(f *filter) {
+func AddGlobalFilter(f Condition) {
 	globalMutex.Lock()
 	defer globalMutex.Unlock()
 	globalFilters = append(globalFilters, f)
@@ -86,37 +85,68 @@ func ClearGlobalFilters() {
 GetGlobalFilters returns a copy of the current global filters.
 This function is thread-safe.
 */
-func GetGlobalFilters() []*filter {
+func GetGlobalFilters() []Condition {
 	globalMutex.RLock()
 	defer globalMutex.RUnlock()
 	// Return a copy to prevent external modifications
-	result := make([]*filter, len(globalFilters))
+	result := make([]Condition, len(globalFilters))
 	copy(result, globalFilters)
 	return result
 }
 
-type filter struct {
+/*
+Condition is a query expression that can be composed with And, Or, NOR and global filters.
+*FilterBuilder implements it, custom condition types can implement it to plug their own operators into kyte.
+
+	type textSearch string
+
+	func (t textSearch) Build() (bson.D, error) {
+		return bson.D{{Key: "$text", Value: bson.M{"$search": string(t)}}}, nil
+	}
+
+	Filter().
+		Equal("status", "active").
+		And(textSearch("coffee")) // {"status": {"$eq": "active"}, "$and": [{"$text": {"$search": "coffee"}}]}
+*/
+type Condition interface {
+	Build() (bson.D, error)
+}
+
+/*
+FilterBuilder builds a mongo query, it is created by [Filter].
+*/
+type FilterBuilder struct {
 	kyte       *kyte
 	query      bson.D
 	operations []operation
 
-	isBuild bool
+	isBuild  bool
+	negate   bool
+	globals  int
+	optimize bool
 }
 
 /*
-Filter creates a new filter instance.
+Filter creates a new filter instance. The options of the parsers such as [AllowFields] and of [FromExample] such as [IgnoreCase]
+are not used by Filter, Build returns [ErrUnsupportedOption] for them.
 */
-func Filter(opts ...OptionFunc) *filter {
-	options := &Options{validateField: true}
-	for _, opt := range opts {
-		opt(options)
-	}
+func Filter(opts ...OptionFunc) *FilterBuilder {
+	options := newOptions(opts)
+	f := newFilter(options)
+	f.kyte.setError(options.unsupported(0))
+	return f
+}
 
-	kyte := newKyte(options.source, options.validateField)
+/*
+newFilter creates a filter with the given options, it is used by the parsers whose options are not a part of the filter.
+*/
+func newFilter(options *Options) *FilterBuilder {
+	kyte := newKyteWithOptions(options)
 
-	f := &filter{
-		kyte:  kyte,
-		query: bson.D{},
+	f := &FilterBuilder{
+		kyte:     kyte,
+		query:    bson.D{},
+		optimize: options.optimize,
 	}
 
 	if !options.ignoreGlobalFilters {
@@ -129,6 +159,7 @@ func Filter(opts ...OptionFunc) *filter {
 		globalMutex.RUnlock()
 	}
 
+	f.globals = len(f.query)
 	return f
 }
 
@@ -140,7 +171,7 @@ Equal use mongo [$eq] operator to compare field and value.
 
 [$eq]: https://www.mongodb.com/docs/manual/reference/operator/query/eq/#mongodb-query-op.-eq
 */
-func (f *filter) Equal(field any, value any) *filter {
+func (f *FilterBuilder) Equal(field any, value any) *FilterBuilder {
 	return f.set(eq, field, value, true)
 }
 
@@ -152,7 +183,7 @@ NotEqual use mongo [$ne] operator to compare field and value.
 
 [$ne]: https://www.mongodb.com/docs/manual/reference/operator/query/ne/#mongodb-query-op.-ne
 */
-func (f *filter) NotEqual(field any, value any) *filter {
+func (f *FilterBuilder) NotEqual(field any, value any) *FilterBuilder {
 	return f.set(ne, field, value, true)
 }
 
@@ -164,7 +195,7 @@ GreaterThan use mongo [$gt] operator to compare field and value.
 
 [$gt]: https://www.mongodb.com/docs/manual/reference/operator/query/gt/#mongodb-query-op.-gt
 */
-func (f *filter) GreaterThan(field any, value any) *filter {
+func (f *FilterBuilder) GreaterThan(field any, value any) *FilterBuilder {
 	return f.set(gt, field, value, true)
 }
 
@@ -175,7 +206,7 @@ Example: GreaterThanOrEqual("age", 18) => {"age": {"$gte": 18}}
 
 [$gte]: https://www.mongodb.com/docs/manual/reference/operator/query/gte/#mongodb-query-op.-gte
 */
-func (f *filter) GreaterThanOrEqual(field any, value any) *filter {
+func (f *FilterBuilder) GreaterThanOrEqual(field any, value any) *FilterBuilder {
 	return f.set(gte, field, value, true)
 }
 
@@ -187,7 +218,7 @@ LessThan use mongo [$lt] operator to compare field and value.
 
 [$lt]: https://www.mongodb.com/docs/manual/reference/operator/query/lt/#mongodb-query-op.-lt
 */
-func (f *filter) LessThan(field any, value any) *filter {
+func (f *FilterBuilder) LessThan(field any, value any) *FilterBuilder {
 	return f.set(lt, field, value, true)
 }
 
@@ -199,7 +230,7 @@ LessThanOrEqual use mongo [$lte] operator to compare field and value.
 
 [$lte]: https://www.mongodb.com/docs/manual/reference/operator/query/lte/#mongodb-query-op.-lte
 */
-func (f *filter) LessThanOrEqual(field any, value any) *filter {
+func (f *FilterBuilder) LessThanOrEqual(field any, value any) *FilterBuilder {
 	return f.set(lte, field, value, true)
 }
 
@@ -211,7 +242,7 @@ In use mongo [$in] operator to compare field and value.
 
 [$in]: https://www.mongodb.com/docs/manual/reference/operator/query/in/#mongodb-query-op.-in
 */
-func (f *filter) In(field any, value any) *filter {
+func (f *FilterBuilder) In(field any, value any) *FilterBuilder {
 	return f.set(in, field, value, true)
 }
 
@@ -223,7 +254,7 @@ NotIn use mongo [$nin] operator to compare field and value.
 
 [$nin]: https://www.mongodb.com/docs/manual/reference/operator/query/nin/#mongodb-query-op.-nin
 */
-func (f *filter) NotIn(field any, value any) *filter {
+func (f *FilterBuilder) NotIn(field any, value any) *FilterBuilder {
 	return f.set(nin, field, value, true)
 }
 
@@ -240,27 +271,8 @@ And use mongo [$and] logical query operator to combine multiple query expression
 
 [$and]: https://www.mongodb.com/docs/manual/reference/operator/query/and/#mongodb-query-op.-and
 */
-func (f *filter) And(filter *filter) *filter {
-	if f.kyte.source != nil {
-		filter.kyte.checkField = f.kyte.checkField
-		filter.kyte.setSourceAndPrepareFields(f.kyte.source)
-	}
-
-	filter.query = nil // reset the query to avoid appending global filters again
-
-	query, err := filter.Build()
-	if err != nil {
-		f.kyte.setError(err)
-		return f
-	}
-
-	andQuery := bson.A{}
-	for _, q := range query {
-		andQuery = append(andQuery, bson.M{q.Key: q.Value})
-	}
-
-	f.query = append(f.query, bson.E{Key: and, Value: andQuery})
-	return f
+func (f *FilterBuilder) And(condition Condition) *FilterBuilder {
+	return f.logical(and, condition)
 }
 
 /*
@@ -276,27 +288,8 @@ Or use mongo [$or] logical query operator to combine multiple query expressions.
 
 [$or]: https://www.mongodb.com/docs/manual/reference/operator/query/or/#mongodb-query-op.-or
 */
-func (f *filter) Or(filter *filter) *filter {
-	if f.kyte.source != nil {
-		filter.kyte.checkField = f.kyte.checkField
-		filter.kyte.setSourceAndPrepareFields(f.kyte.source)
-	}
-
-	filter.query = nil // reset the query to avoid appending global filters again
-
-	query, err := filter.Build()
-	if err != nil {
-		f.kyte.setError(err)
-		return f
-	}
-
-	orQuery := bson.A{}
-	for _, q := range query {
-		orQuery = append(orQuery, bson.M{q.Key: q.Value})
-	}
-
-	f.query = append(f.query, bson.E{Key: or, Value: orQuery})
-	return f
+func (f *FilterBuilder) Or(condition Condition) *FilterBuilder {
+	return f.logical(or, condition)
 }
 
 /*
@@ -312,29 +305,83 @@ NOR use mongo [$nor] logical query operator to combine multiple query expression
 
 [$nor]: https://www.mongodb.com/docs/manual/reference/operator/query/nor/#mongodb-query-op.-nor
 */
-func (f *filter) NOR(filter *filter) *filter {
-	if f.kyte.source != nil {
-		filter.kyte.checkField = f.kyte.checkField
-		filter.kyte.setSourceAndPrepareFields(f.kyte.source)
-	}
-
-	filter.query = nil // reset the query to avoid appending global filters again
+func (f *FilterBuilder) NOR(condition Condition) *FilterBuilder {
+	return f.logical(nor, condition)
+}
 
-	query, err := filter.Build()
+/*
+logical builds the condition and appends its expressions to the query with the given logical operator.
+*/
+func (f *FilterBuilder) logical(operator string, condition Condition) *FilterBuilder {
+	query, err := f.buildCondition(condition)
 	if err != nil {
 		f.kyte.setError(err)
 		return f
 	}
 
-	norQuery := bson.A{}
+	items := bson.A{}
 	for _, q := range query {
-		norQuery = append(norQuery, bson.M{q.Key: q.Value})
+		items = append(items, bson.M{q.Key: q.Value})
 	}
 
-	f.query = append(f.query, bson.E{Key: nor, Value: norQuery})
+	f.query = append(f.query, bson.E{Key: operator, Value: items})
 	return f
 }
 
+/*
+With adds the expressions of the given conditions to the top level of the query, it is useful to combine the typed conditions of [Field].
+Like the logical operators, the expressions are placed before the conditions of the field methods such as [FilterBuilder.Equal].
+
+	s := For[User]()
+	age := FieldOf(s, &s.Model.Age)
+
+	s.Filter().
+		Equal("name", "John").
+		With(age.GreaterThan(18), age.LessThan(65)) // {"age": {"$gt": 18}, "age": {"$lt": 65}, "name": {"$eq": "John"}}
+*/
+func (f *FilterBuilder) With(conditions ...Condition) *FilterBuilder {
+	for _, condition := range conditions {
+		query, err := f.buildCondition(condition)
+		if err != nil {
+			f.kyte.setError(err)
+			return f
+		}
+
+		f.query = append(f.query, query...)
+	}
+
+	return f
+}
+
+/*
+buildCondition builds the given condition, the source of the filter is passed to the condition if it is a *FilterBuilder.
+*/
+func (f *FilterBuilder) buildCondition(condition Condition) (bson.D, error) {
+	if f.negate {
+		return nil, ErrNotWithoutOperator
+	}
+
+	if condition == nil {
+		return nil, ErrNilFilter
+	}
+
+	if filter, ok := condition.(*FilterBuilder); ok {
+		if filter == nil {
+			return nil, ErrNilFilter
+		}
+
+		if f.kyte.source != nil {
+			filter.kyte.checkField = f.kyte.checkField
+			filter.kyte.options = f.kyte.options
+			filter.kyte.setSourceAndPrepareFields(f.kyte.source)
+		}
+
+		filter.dropGlobalFilters() // avoid appending global filters again
+	}
+
+	return condition.Build()
+}
+
 /*
 Regex use mongo [$regex] operator to compare field and value.
 
@@ -349,7 +396,7 @@ Regex use mongo [$regex] operator to compare field and value.
 
 [$regex]: https://www.mongodb.com/docs/manual/reference/operator/query/regex/#mongodb-query-op.-regex
 */
-func (f *filter) Regex(field any, regex *regexp.Regexp, options ...string) *filter {
+func (f *FilterBuilder) Regex(field any, regex *regexp.Regexp, options ...string) *FilterBuilder {
 	if regex == nil {
 		f.kyte.setError(ErrRegexCannotBeNil)
 		return f
@@ -370,7 +417,7 @@ Exists use mongo [$exists] operator to check if the field exists.
 
 [$exists]: https://www.mongodb.com/docs/manual/reference/operator/query/exists/#mongodb-query-op.-exists
 */
-func (f *filter) Exists(field any, value bool) *filter {
+func (f *FilterBuilder) Exists(field any, value bool) *FilterBuilder {
 	return f.set(exists, field, value, true)
 }
 
@@ -385,7 +432,7 @@ Type use mongo [$type] operator to check if the field is of the specified type.
 
 [$type]: https://www.mongodb.com/docs/manual/reference/operator/query/type/#mongodb-query-op.-type
 */
-func (f *filter) Type(field any, values ...bsontype.Type) *filter {
+func (f *FilterBuilder) Type(field any, values ...bsontype.Type) *FilterBuilder {
 	if len(values) == 0 {
 		f.kyte.setError(ErrInvalidBsonType)
 		return f
@@ -409,7 +456,7 @@ Mod use mongo [$mod] operator to check if the field is a multiple of a specified
 
 [$mod]: https://www.mongodb.com/docs/manual/reference/operator/query/mod/#mongodb-query-op.-mod
 */
-func (f *filter) Mod(field any, divisor int, remainder int) *filter {
+func (f *FilterBuilder) Mod(field any, divisor int, remainder int) *FilterBuilder {
 	return f.set(mod, field, bson.A{divisor, remainder}, true)
 }
 
@@ -421,7 +468,7 @@ Where use mongo [$where] operator to pass a javascript expression to the query s
 
 [$where]: https://www.mongodb.com/docs/manual/reference/operator/query/where/#mongodb-query-op.-where
 */
-func (f *filter) Where(js string) *filter {
+func (f *FilterBuilder) Where(js string) *FilterBuilder {
 	return f.set(where, nil, js, false)
 }
 
@@ -433,7 +480,7 @@ All use mongo [$all] operator to check if the field contains all of the specifie
 
 [$all]: https://www.mongodb.com/docs/manual/reference/operator/query/all/#mongodb-query-op.-all
 */
-func (f *filter) All(field any, value any) *filter {
+func (f *FilterBuilder) All(field any, value any) *FilterBuilder {
 	if reflect.TypeOf(value).Kind() != reflect.Slice {
 		f.kyte.setError(ErrValueMustBeSlice)
 		return f
@@ -450,7 +497,7 @@ Size use mongo [$size] operator to check if the field is an array that contains
 
 [$size]: https://www.mongodb.com/docs/manual/reference/operator/query/size/#mongodb-query-op.-size
 */
-func (f *filter) Size(field any, value int) *filter {
+func (f *FilterBuilder) Size(field any, value int) *FilterBuilder {
 	return f.set(size, field, value, true)
 }
 
@@ -462,10 +509,56 @@ JSONSchema use mongo [$jsonSchema] operator to validate documents against the gi
 
 [$jsonSchema]: https://www.mongodb.com/docs/manual/reference/operator/query/jsonSchema/#mongodb-query-op.-jsonSchema
 */
-func (f *filter) JSONSchema(schema bson.M) *filter {
+func (f *FilterBuilder) JSONSchema(schema bson.M) *FilterBuilder {
 	return f.set(jsonSchema, nil, schema, false)
 }
 
+/*
+ElemMatch use mongo [$elemMatch] operator to match documents that contain an array field with at least one element that matches all the given filter conditions.
+The given filter is scoped to the array element, if a source is set its fields are validated against the element struct instead of the source struct.
+The given filter is built by [FilterBuilder.Build], so it also uses the source that the filter gets from a parent filter such as [FilterBuilder.And].
+
+	Filter().
+		ElemMatch("items", Filter().Equal("name", "pen").GreaterThan("qty", 5)) // {"items": {"$elemMatch": {"name": {"$eq": "pen"}, "qty": {"$gt": 5}}}}
+
+For arrays of scalar values use [Elem] as the field of the given filter conditions.
+
+	Filter().
+		ElemMatch("scores", Filter().GreaterThanOrEqual(kyte.Elem, 80).LessThan(kyte.Elem, 85)) // {"scores": {"$elemMatch": {"$gte": 80, "$lt": 85}}}
+
+[$elemMatch]: https://www.mongodb.com/docs/manual/reference/operator/query/elemMatch/#mongodb-query-op.-elemMatch
+*/
+func (f *FilterBuilder) ElemMatch(field any, filter *FilterBuilder) *FilterBuilder {
+	if filter == nil {
+		f.kyte.setError(ErrNilFilter)
+		return f
+	}
+
+	return f.set(elemMatch, field, filter, true)
+}
+
+/*
+Not use mongo [$not] operator to negate the next field level operator.
+It can be used with any operator that requires a field such as Equal, GreaterThan, In, Regex, Type, Mod, Size or ElemMatch.
+
+	Filter().
+		Not().GreaterThan("age", 30) // {"age": {"$not": {"$gt": 30}}}
+
+	Filter().
+		Not().Regex("name", regexp.MustCompile("^J"), "i") // {"name": {"$not": {"$regex": "^J", "$options": "i"}}}
+
+[$not]: https://www.mongodb.com/docs/manual/reference/operator/query/not/#mongodb-query-op.-not
+*/
+func (f *FilterBuilder) Not() *FilterBuilder {
+	if f.negate {
+		f.kyte.setError(ErrNotWithoutOperator)
+		return f
+	}
+
+	f.negate = true
+	return f
+}
+
 /*
 Raw use raw bson.D and directly append it to the query. It is useful for using operators that are not implemented in this package.
 Raw will not provide any validation, so it is recommended to use it carefully.
@@ -473,7 +566,12 @@ Raw will not provide any validation, so it is recommended to use it carefully.
 	Filter().
 		Raw(bson.D{{"name", "John"}}) // {"name": "John"}
 */
-func (f *filter) Raw(query bson.D) *filter {
+func (f *FilterBuilder) Raw(query bson.D) *FilterBuilder {
+	if f.negate {
+		f.kyte.setError(ErrNotWithoutOperator)
+		return f
+	}
+
 	f.query = append(f.query, query...)
 	return f
 }
@@ -481,7 +579,7 @@ func (f *filter) Raw(query bson.D) *filter {
 /*
 Build returns the query as bson.M. If there is an error, it will return nil and the first error.
 */
-func (f *filter) Build() (bson.D, error) {
+func (f *FilterBuilder) Build() (bson.D, error) {
 	if f.kyte.hasErrors() {
 		return nil, f.kyte.err
 	}
@@ -490,6 +588,11 @@ func (f *filter) Build() (bson.D, error) {
 		return f.query, nil
 	}
 
+	if f.negate {
+		f.kyte.setError(ErrNotWithoutOperator)
+		return nil, f.kyte.err
+	}
+
 	for _, opt := range f.operations {
 		err := f.kyte.validate(&opt)
 		if err != nil {
@@ -513,6 +616,16 @@ func (f *filter) Build() (bson.D, error) {
 			break
 		}
 
+		if opt.operator == elemMatch {
+			// the element filter is built here so it is scoped with the source that the filter has at build time
+			query, err := f.kyte.buildElemFilter(opt.field, opt.value.(*FilterBuilder))
+			if err != nil {
+				f.kyte.setError(err)
+				break
+			}
+			opt.value = bson.M{elemMatch: query}
+		}
+
 		if opt.value != nil && reflect.TypeOf(opt.value).Kind() == reflect.Ptr {
 			opt.value = reflect.ValueOf(opt.value).Elem().Interface()
 		}
@@ -523,34 +636,129 @@ func (f *filter) Build() (bson.D, error) {
 			}
 		}
 
-		if opt.operator == regx {
-			f.query = append(f.query, bson.E{Key: fieldName, Value: opt.value})
-			continue
+		if err := f.kyte.checkValueType(fieldName, opt.operator, opt.value); err != nil {
+			f.kyte.setError(err)
+			break
+		}
+
+		value := bson.M{opt.operator: opt.value}
+		if opt.operator == regx || opt.operator == elemMatch {
+			value = opt.value.(bson.M)
 		}
 
-		f.query = append(f.query, bson.E{Key: fieldName, Value: bson.M{opt.operator: opt.value}})
+		if opt.negate {
+			value = bson.M{not: value}
+		}
+
+		f.query = append(f.query, bson.E{Key: fieldName, Value: value})
 	}
 
 	if f.kyte.hasErrors() {
 		return nil, f.kyte.err
 	}
 
+	if f.optimize {
+		query, err := optimizeQuery(f.query[f.globals:], f.kyte.scalarPaths())
+		if err != nil {
+			f.kyte.setError(err)
+			return nil, f.kyte.err
+		}
+		f.query = append(f.query[:f.globals:f.globals], query...)
+	}
+
 	f.isBuild = true
 	return f.query, nil
 }
 
-func (f *filter) set(operator string, field any, value any, isFieldRequired bool) *filter {
+/*
+dropGlobalFilters removes the global filters from the query, the conditions that are already built are kept.
+*/
+func (f *FilterBuilder) dropGlobalFilters() {
+	f.query = f.query[f.globals:]
+	f.globals = 0
+}
+
+func (f *FilterBuilder) set(operator string, field any, value any, isFieldRequired bool) *FilterBuilder {
+	if f.negate && !isFieldRequired {
+		f.kyte.setError(ErrNotWithoutOperator)
+		return f
+	}
+
 	f.operations = append(f.operations, operation{
 		operator:        operator,
 		field:           field,
 		value:           value,
 		isFieldRequired: isFieldRequired,
+		negate:          f.negate,
 	})
+	f.negate = false
 
 	return f
 }
 
-func (f *filter) ToJSON() (string, error) {
+/*
+buildElemFilter scopes the given filter to the element of the array field and returns the query that can be used as an element condition.
+Conditions set on the [Elem] field are merged into the top level of the query so they are applied to the element itself.
+*/
+func (k *kyte) buildElemFilter(field any, filter *FilterBuilder) (bson.D, error) {
+	if err := k.scopeElemFilter(field, filter); err != nil {
+		return nil, err
+	}
+
+	query, err := filter.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	elemQuery := bson.D{}
+	for _, q := range query {
+		if q.Key != Elem {
+			elemQuery = append(elemQuery, q)
+			continue
+		}
+
+		operators, ok := q.Value.(bson.M)
+		if !ok {
+			elemQuery = append(elemQuery, q)
+			continue
+		}
+
+		keys := make([]string, 0, len(operators))
+		for key := range operators {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			elemQuery = append(elemQuery, bson.E{Key: key, Value: operators[key]})
+		}
+	}
+
+	return elemQuery, nil
+}
+
+/*
+scopeElemFilter sets the element of the given array field as the source of the filter.
+*/
+func (k *kyte) scopeElemFilter(field any, filter *FilterBuilder) error {
+	if k.source != nil && field != nil {
+		elem, err := k.elemSource(field)
+		if err != nil {
+			return err
+		}
+
+		if elem != nil && (filter.kyte.source == nil || reflect.TypeOf(filter.kyte.source) != reflect.TypeOf(elem)) {
+			filter.kyte.checkField = k.checkField
+			filter.kyte.options = k.options
+			filter.kyte.setSourceAndPrepareFields(elem)
+		}
+	}
+
+	filter.dropGlobalFilters() // avoid appending global filters again
+	return nil
+}
+
+func (f *FilterBuilder) ToJSON() (string, error) {
 	query, err := f.Build()
 	if err != nil {
 		return "", err
@@ -2,6 +2,7 @@ package kyte
 
 import (
 	"fmt"
+	"reflect"
 	"testing"
 )
 
@@ -58,7 +59,7 @@ func BenchmarkFilterWithIgnoredGlobal(b *testing.B) {
 	}
 }
 
-func buildFilterWithSize(size int) *filter {
+func buildFilterWithSize(size int) *FilterBuilder {
 	f := Filter()
 	// Add base conditions
 	f.Equal("name", "John").
@@ -73,3 +74,63 @@ func buildFilterWithSize(size int) *filter {
 
 	return f
 }
+
+type benchmarkAddress struct {
+	City    string `bson:"city"`
+	Country string `bson:"country"`
+	Geo     struct {
+		Lat float64 `bson:"lat"`
+		Lng float64 `bson:"lng"`
+	} `bson:"geo"`
+}
+
+type benchmarkOrder struct {
+	ID    string  `bson:"id"`
+	Total float64 `bson:"total"`
+}
+
+type benchmarkNestedSource struct {
+	Name     string            `bson:"name"`
+	Age      int               `bson:"age"`
+	Address  benchmarkAddress  `bson:"address"`
+	Billing  *benchmarkAddress `bson:"billing"`
+	Orders   []benchmarkOrder  `bson:"orders"`
+	Tags     []string          `bson:"tags"`
+	Metadata map[string]string `bson:"metadata"`
+}
+
+func BenchmarkFilterNestedSource(b *testing.B) {
+	ClearGlobalFilters()
+	source := benchmarkNestedSource{Billing: &benchmarkAddress{}, Orders: []benchmarkOrder{{}}}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = Filter(Source(&source)).
+			Equal(&source.Name, "John").
+			Equal(&source.Address.Geo.Lat, 41.0).
+			And(Filter(Source(&source)).
+				Equal(&source.Billing.City, "Istanbul").
+				GreaterThan(&source.Orders[0].Total, 100.0)).
+			Build()
+	}
+}
+
+func BenchmarkSourceSchema(b *testing.B) {
+	source := benchmarkNestedSource{Billing: &benchmarkAddress{}, Orders: []benchmarkOrder{{}}}
+	sourceType := reflect.TypeOf(source)
+
+	b.Run("cached", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = newKyte(&source, true)
+		}
+	})
+
+	b.Run("uncached", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, _ = buildSchema(sourceType, schemaOptions{})
+		}
+	})
+}
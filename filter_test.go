@@ -1,16 +1,60 @@
 package kyte_test
 
 import (
+	"errors"
 	"reflect"
 	"regexp"
 	"testing"
+	"time"
 
 	"github.com/aaydin-tr/kyte"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsoncodec"
 	"go.mongodb.org/mongo-driver/bson/bsontype"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+type TestAddress struct {
+	City    string `bson:"city"`
+	ZipCode string `bson:"zip_code"`
+}
+
+// TestUser is the source of the tests of the parsers and FromExample.
+type TestUser struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	Name      string             `bson:"name"`
+	Age       int                `bson:"age"`
+	Email     string             `bson:"email_address"`
+	Score     float64            `bson:"score"`
+	Active    bool               `bson:"active"`
+	Status    string             `bson:"status"`
+	Tags      []string           `bson:"tags"`
+	Address   TestAddress        `bson:"address"`
+	Manager   *TestUser          `bson:"manager"`
+	Labels    map[string]string  `bson:"labels"`
+	CreatedAt time.Time          `bson:"created_at"`
+	DeletedAt *time.Time         `bson:"deleted_at"`
+	Nickname  string             `bson:"-"`
+	password  string
+}
+
+func testParsedFilter(t *testing.T, parser string, f *kyte.FilterBuilder, err error, target bson.D) {
+	t.Helper()
+
+	if err != nil {
+		t.Fatalf("%s should not return error: %v", parser, err)
+	}
+
+	q, err := f.Build()
+	if err != nil {
+		t.Errorf("Filter.Build should not return error: %v", err)
+	}
+
+	if !reflect.DeepEqual(q, target) {
+		t.Errorf("%s should return value %v, got %v", parser, target, q)
+	}
+}
+
 func TestFilter_Equal(t *testing.T) {
 	t.Parallel()
 
@@ -1462,6 +1506,504 @@ func TestFilter_JSONSchema(t *testing.T) {
 	})
 }
 
+func TestFilter_ElemMatch(t *testing.T) {
+	t.Parallel()
+
+	t.Run("without source", func(t *testing.T) {
+		q, err := kyte.Filter().
+			ElemMatch("items", kyte.Filter().Equal("name", "pen").GreaterThan("qty", 5)).
+			Build()
+		if err != nil {
+			t.Errorf("Filter.ElemMatch should not return error: %v", err)
+		}
+
+		target := bson.D{{Key: "items", Value: bson.M{"$elemMatch": bson.D{
+			{Key: "name", Value: bson.M{"$eq": "pen"}},
+			{Key: "qty", Value: bson.M{"$gt": 5}},
+		}}}}
+
+		if !reflect.DeepEqual(q, target) {
+			t.Errorf("Filter.ElemMatch should return value %v, got %v", target, q)
+		}
+	})
+
+	t.Run("scalar array", func(t *testing.T) {
+		q, err := kyte.Filter().
+			ElemMatch("scores", kyte.Filter().GreaterThanOrEqual(kyte.Elem, 80).LessThan(kyte.Elem, 85)).
+			Build()
+		if err != nil {
+			t.Errorf("Filter.ElemMatch should not return error: %v", err)
+		}
+
+		target := bson.D{{Key: "scores", Value: bson.M{"$elemMatch": bson.D{
+			{Key: "$gte", Value: 80},
+			{Key: "$lt", Value: 85},
+		}}}}
+
+		if !reflect.DeepEqual(q, target) {
+			t.Errorf("Filter.ElemMatch should return value %v, got %v", target, q)
+		}
+	})
+
+	t.Run("with source", func(t *testing.T) {
+		type Item struct {
+			Name string `bson:"name"`
+			Qty  int    `bson:"qty"`
+		}
+
+		type Temp struct {
+			Items  []Item `bson:"items"`
+			Scores []int  `bson:"scores"`
+		}
+
+		var temp Temp
+		q, err := kyte.Filter(kyte.Source(&temp)).
+			ElemMatch(&temp.Items, kyte.Filter().Equal("name", "pen").GreaterThan("qty", 5)).
+			ElemMatch(&temp.Scores, kyte.Filter().GreaterThan(kyte.Elem, 80)).
+			Build()
+		if err != nil {
+			t.Errorf("Filter.ElemMatch should not return error: %v", err)
+		}
+
+		if q[0].Key != "items" {
+			t.Errorf("Filter.ElemMatch should return key items, got %v", q[0].Key)
+		}
+
+		if q[1].Key != "scores" {
+			t.Errorf("Filter.ElemMatch should return key scores, got %v", q[1].Key)
+		}
+	})
+
+	t.Run("with source and pointer of first element", func(t *testing.T) {
+		type Item struct {
+			Name string `bson:"name"`
+			Qty  int    `bson:"qty"`
+		}
+
+		type Temp struct {
+			Items []*Item `bson:"items"`
+		}
+
+		item := Item{}
+		temp := Temp{Items: []*Item{&item}}
+		q, err := kyte.Filter(kyte.Source(&temp)).
+			ElemMatch(&temp.Items, kyte.Filter().Equal(&item.Name, "pen")).
+			Build()
+		if err != nil {
+			t.Errorf("Filter.ElemMatch should not return error: %v", err)
+		}
+
+		if q[0].Value.(bson.M)["$elemMatch"].(bson.D)[0].Key != "name" {
+			t.Errorf("Filter.ElemMatch should return key name, got %v", q[0].Value)
+		}
+	})
+
+	t.Run("field not in element struct", func(t *testing.T) {
+		type Item struct {
+			Name string `bson:"name"`
+		}
+
+		type Temp struct {
+			Name  string `bson:"name"`
+			Items []Item `bson:"items"`
+		}
+
+		var temp Temp
+		_, err := kyte.Filter(kyte.Source(&temp)).
+			ElemMatch(&temp.Items, kyte.Filter().Equal("items.name", "pen")).
+			Build()
+		if !errors.Is(err, kyte.ErrNotValidFieldForQuery) {
+			t.Errorf("Filter.ElemMatch should return error %v, got %v", kyte.ErrNotValidFieldForQuery, err)
+		}
+	})
+
+	t.Run("source of the parent filter", func(t *testing.T) {
+		type Item struct {
+			Name string `bson:"name"`
+		}
+
+		type Temp struct {
+			Items []Item `bson:"items"`
+		}
+
+		var temp Temp
+		_, err := kyte.Filter(kyte.Source(&temp)).
+			And(kyte.Filter().ElemMatch("items", kyte.Filter().Equal("nmae", "pen"))).
+			Build()
+		if !errors.Is(err, kyte.ErrNotValidFieldForQuery) {
+			t.Errorf("Filter.ElemMatch should return error %v, got %v", kyte.ErrNotValidFieldForQuery, err)
+		}
+
+		q, err := kyte.Filter(kyte.Source(&temp)).
+			Or(kyte.Filter().ElemMatch("items", kyte.Filter().Equal("name", "pen"))).
+			Build()
+		if err != nil {
+			t.Errorf("Filter.ElemMatch should not return error: %v", err)
+		}
+
+		target := bson.D{{Key: "$or", Value: bson.A{
+			bson.M{"items": bson.M{"$elemMatch": bson.D{{Key: "name", Value: bson.M{"$eq": "pen"}}}}},
+		}}}
+		if !reflect.DeepEqual(q, target) {
+			t.Errorf("Filter.ElemMatch should return value %v, got %v", target, q)
+		}
+	})
+
+	t.Run("field is not an array", func(t *testing.T) {
+		type Temp struct {
+			Name string `bson:"name"`
+		}
+
+		var temp Temp
+		_, err := kyte.Filter(kyte.Source(&temp)).
+			ElemMatch(&temp.Name, kyte.Filter().Equal(kyte.Elem, "kyte")).
+			Build()
+		if err != kyte.ErrFieldMustBeArray {
+			t.Errorf("Filter.ElemMatch should return error %v, got %v", kyte.ErrFieldMustBeArray, err)
+		}
+	})
+
+	t.Run("nil filter", func(t *testing.T) {
+		_, err := kyte.Filter().ElemMatch("items", nil).Build()
+		if err != kyte.ErrNilFilter {
+			t.Errorf("Filter.ElemMatch should return error %v, got %v", kyte.ErrNilFilter, err)
+		}
+	})
+}
+
+func TestFilter_Not(t *testing.T) {
+	t.Parallel()
+
+	t.Run("without source", func(t *testing.T) {
+		q, err := kyte.Filter().
+			Not().GreaterThan("age", 30).
+			Not().In("name", []string{"John", "Jane"}).
+			Not().Regex("surname", regexp.MustCompile("^D"), "i").
+			Not().Size("tags", 2).
+			Equal("active", true).
+			Build()
+		if err != nil {
+			t.Errorf("Filter.Not should not return error: %v", err)
+		}
+
+		target := bson.D{
+			{Key: "age", Value: bson.M{"$not": bson.M{"$gt": 30}}},
+			{Key: "name", Value: bson.M{"$not": bson.M{"$in": []string{"John", "Jane"}}}},
+			{Key: "surname", Value: bson.M{"$not": bson.M{"$regex": "^D", "$options": "i"}}},
+			{Key: "tags", Value: bson.M{"$not": bson.M{"$size": 2}}},
+			{Key: "active", Value: bson.M{"$eq": true}},
+		}
+
+		if !reflect.DeepEqual(q, target) {
+			t.Errorf("Filter.Not should return value %v, got %v", target, q)
+		}
+	})
+
+	t.Run("with source", func(t *testing.T) {
+		type Temp struct {
+			Name string `bson:"name"`
+			Age  int    `bson:"age"`
+		}
+
+		var temp Temp
+		q, err := kyte.Filter(kyte.Source(&temp)).
+			Not().Mod(&temp.Age, 2, 0).
+			Not().Type(&temp.Name, bson.TypeString).
+			Build()
+		if err != nil {
+			t.Errorf("Filter.Not should not return error: %v", err)
+		}
+
+		if q[0].Key != "age" || q[0].Value.(bson.M)["$not"].(bson.M)["$mod"] == nil {
+			t.Errorf("Filter.Not should return value map[$not:map[$mod:[2 0]]], got %v", q[0].Value)
+		}
+
+		if q[1].Key != "name" || q[1].Value.(bson.M)["$not"].(bson.M)["$type"] == nil {
+			t.Errorf("Filter.Not should return value map[$not:map[$type:[2]]], got %v", q[1].Value)
+		}
+	})
+
+	t.Run("with source and invalid field", func(t *testing.T) {
+		type Temp struct {
+			Name string `bson:"name"`
+		}
+
+		var temp Temp
+		_, err := kyte.Filter(kyte.Source(&temp)).Not().Equal("age", 10).Build()
+		if !errors.Is(err, kyte.ErrNotValidFieldForQuery) {
+			t.Errorf("Filter.Not should return error %v, got %v", kyte.ErrNotValidFieldForQuery, err)
+		}
+	})
+
+	t.Run("without operator", func(t *testing.T) {
+		_, err := kyte.Filter().Equal("name", "kyte").Not().Build()
+		if err != kyte.ErrNotWithoutOperator {
+			t.Errorf("Filter.Not should return error %v, got %v", kyte.ErrNotWithoutOperator, err)
+		}
+	})
+
+	t.Run("with operator without field", func(t *testing.T) {
+		_, err := kyte.Filter().Not().Where("this.name == 'kyte'").Build()
+		if err != kyte.ErrNotWithoutOperator {
+			t.Errorf("Filter.Not should return error %v, got %v", kyte.ErrNotWithoutOperator, err)
+		}
+
+		_, err = kyte.Filter().Not().And(kyte.Filter().Equal("name", "kyte")).Build()
+		if err != kyte.ErrNotWithoutOperator {
+			t.Errorf("Filter.Not should return error %v, got %v", kyte.ErrNotWithoutOperator, err)
+		}
+	})
+
+	t.Run("chained", func(t *testing.T) {
+		_, err := kyte.Filter().Not().Not().Equal("name", "kyte").Build()
+		if err != kyte.ErrNotWithoutOperator {
+			t.Errorf("Filter.Not should return error %v, got %v", kyte.ErrNotWithoutOperator, err)
+		}
+	})
+}
+
+func TestFilter_Negate(t *testing.T) {
+	t.Run("single condition", func(t *testing.T) {
+		q, err := kyte.Filter(kyte.IgnoreGlobalFilters()).Equal("name", "kyte").Negate().Build()
+		if err != nil {
+			t.Errorf("Filter.Negate should not return error: %v", err)
+		}
+
+		target := bson.D{{Key: "name", Value: bson.M{"$ne": "kyte"}}}
+		if !reflect.DeepEqual(q, target) {
+			t.Errorf("Filter.Negate should return value %v, got %v", target, q)
+		}
+	})
+
+	t.Run("multiple conditions", func(t *testing.T) {
+		q, err := kyte.Filter(kyte.IgnoreGlobalFilters()).
+			Equal("name", "kyte").
+			GreaterThan("age", 10).
+			In("tags", []string{"a", "b"}).
+			Exists("surname", true).
+			Not().LessThanOrEqual("score", 5).
+			Negate().
+			Build()
+		if err != nil {
+			t.Errorf("Filter.Negate should not return error: %v", err)
+		}
+
+		target := bson.D{{Key: "$or", Value: bson.A{
+			bson.M{"name": bson.M{"$ne": "kyte"}},
+			bson.M{"age": bson.M{"$not": bson.M{"$gt": 10}}},
+			bson.M{"tags": bson.M{"$nin": []string{"a", "b"}}},
+			bson.M{"surname": bson.M{"$exists": false}},
+			bson.M{"score": bson.M{"$lte": 5}},
+		}}}
+		if !reflect.DeepEqual(q, target) {
+			t.Errorf("Filter.Negate should return value %v, got %v", target, q)
+		}
+	})
+
+	t.Run("logical operators", func(t *testing.T) {
+		q, err := kyte.Filter(kyte.IgnoreGlobalFilters()).
+			And(kyte.Filter().Equal("name", "kyte").LessThan("age", 10)).
+			Negate().
+			Build()
+		if err != nil {
+			t.Errorf("Filter.Negate should not return error: %v", err)
+		}
+
+		target := bson.D{{Key: "$or", Value: bson.A{
+			bson.M{"name": bson.M{"$ne": "kyte"}},
+			bson.M{"age": bson.M{"$not": bson.M{"$lt": 10}}},
+		}}}
+		if !reflect.DeepEqual(q, target) {
+			t.Errorf("Filter.Negate should return value %v, got %v", target, q)
+		}
+
+		q, err = kyte.Filter(kyte.IgnoreGlobalFilters()).
+			Or(kyte.Filter().Equal("name", "kyte").NotIn("age", []int{1, 2})).
+			NOR(kyte.Filter().Equal("surname", "joe")).
+			Negate().
+			Build()
+		if err != nil {
+			t.Errorf("Filter.Negate should not return error: %v", err)
+		}
+
+		target = bson.D{{Key: "$or", Value: bson.A{
+			bson.M{"$and": bson.A{
+				bson.M{"name": bson.M{"$ne": "kyte"}},
+				bson.M{"age": bson.M{"$in": []int{1, 2}}},
+			}},
+			bson.M{"$or": bson.A{
+				bson.M{"surname": bson.M{"$eq": "joe"}},
+			}},
+		}}}
+		if !reflect.DeepEqual(q, target) {
+			t.Errorf("Filter.Negate should return value %v, got %v", target, q)
+		}
+	})
+
+	t.Run("operators without inverse", func(t *testing.T) {
+		q, err := kyte.Filter(kyte.IgnoreGlobalFilters()).
+			Regex("name", regexp.MustCompile("^k"), "i").
+			Where("this.age > 10").
+			Negate().
+			Build()
+		if err != nil {
+			t.Errorf("Filter.Negate should not return error: %v", err)
+		}
+
+		target := bson.D{{Key: "$or", Value: bson.A{
+			bson.M{"name": bson.M{"$not": primitive.Regex{Pattern: "^k", Options: "i"}}},
+			bson.M{"$nor": bson.A{bson.M{"$where": "this.age > 10"}}},
+		}}}
+		if !reflect.DeepEqual(q, target) {
+			t.Errorf("Filter.Negate should return value %v, got %v", target, q)
+		}
+	})
+
+	t.Run("raw equality", func(t *testing.T) {
+		q, err := kyte.Filter(kyte.IgnoreGlobalFilters()).
+			Raw(bson.D{{Key: "name", Value: "kyte"}}).
+			Negate().
+			Build()
+		if err != nil {
+			t.Errorf("Filter.Negate should not return error: %v", err)
+		}
+
+		target := bson.D{{Key: "name", Value: bson.M{"$ne": "kyte"}}}
+		if !reflect.DeepEqual(q, target) {
+			t.Errorf("Filter.Negate should return value %v, got %v", target, q)
+		}
+	})
+
+	t.Run("global filters are not negated", func(t *testing.T) {
+		kyte.ClearGlobalFilters()
+		defer kyte.ClearGlobalFilters()
+		kyte.AddGlobalFilter(kyte.Filter().Equal("tenantId", "123"))
+
+		q, err := kyte.Filter().Equal("name", "kyte").Negate().GreaterThan("age", 10).Build()
+		if err != nil {
+			t.Errorf("Filter.Negate should not return error: %v", err)
+		}
+
+		target := bson.D{
+			{Key: "tenantId", Value: bson.M{"$eq": "123"}},
+			{Key: "name", Value: bson.M{"$ne": "kyte"}},
+			{Key: "age", Value: bson.M{"$gt": 10}},
+		}
+		if !reflect.DeepEqual(q, target) {
+			t.Errorf("Filter.Negate should return value %v, got %v", target, q)
+		}
+	})
+
+	t.Run("as a child filter", func(t *testing.T) {
+		q, err := kyte.Filter(kyte.IgnoreGlobalFilters()).
+			And(kyte.Filter().Equal("name", "kyte").Negate()).
+			Build()
+		if err != nil {
+			t.Errorf("Filter.Negate should not return error: %v", err)
+		}
+
+		target := bson.D{{Key: "$and", Value: bson.A{bson.M{"name": bson.M{"$ne": "kyte"}}}}}
+		if !reflect.DeepEqual(q, target) {
+			t.Errorf("Filter.Negate should return value %v, got %v", target, q)
+		}
+	})
+
+	t.Run("empty filter", func(t *testing.T) {
+		_, err := kyte.Filter(kyte.IgnoreGlobalFilters()).Negate().Build()
+		if err != kyte.ErrNegateEmptyFilter {
+			t.Errorf("Filter.Negate should return error %v, got %v", kyte.ErrNegateEmptyFilter, err)
+		}
+	})
+
+	t.Run("invalid logical items", func(t *testing.T) {
+		for _, operator := range []string{"$and", "$or", "$nor"} {
+			_, err := kyte.Filter(kyte.IgnoreGlobalFilters()).Raw(bson.D{{Key: operator, Value: bson.A{"name"}}}).Negate().Build()
+			if !errors.Is(err, kyte.ErrInvalidMatchOperand) {
+				t.Errorf("Filter.Negate should return error %v for %s, got %v", kyte.ErrInvalidMatchOperand, operator, err)
+			}
+		}
+	})
+}
+
+type textSearch string
+
+func (t textSearch) Build() (bson.D, error) {
+	return bson.D{{Key: "$text", Value: bson.M{"$search": string(t)}}}, nil
+}
+
+type failingCondition struct{}
+
+func (failingCondition) Build() (bson.D, error) {
+	return nil, errors.New("failing condition")
+}
+
+func TestFilter_Condition(t *testing.T) {
+	t.Run("custom condition", func(t *testing.T) {
+		var builder *kyte.FilterBuilder = kyte.Filter(kyte.IgnoreGlobalFilters())
+		q, err := builder.
+			Equal("status", "active").
+			And(textSearch("coffee")).
+			Or(kyte.Filter().Equal("name", "John")).
+			Build()
+		if err != nil {
+			t.Errorf("Filter.Build should not return error: %v", err)
+		}
+
+		target := bson.D{
+			{Key: "$and", Value: bson.A{bson.M{"$text": bson.M{"$search": "coffee"}}}},
+			{Key: "$or", Value: bson.A{bson.M{"name": bson.M{"$eq": "John"}}}},
+			{Key: "status", Value: bson.M{"$eq": "active"}},
+		}
+		if !reflect.DeepEqual(q, target) {
+			t.Errorf("Filter.Build should return value %v, got %v", target, q)
+		}
+	})
+
+	t.Run("condition error", func(t *testing.T) {
+		_, err := kyte.Filter(kyte.IgnoreGlobalFilters()).NOR(failingCondition{}).Build()
+		if err == nil || err.Error() != "failing condition" {
+			t.Errorf("Filter.Build should return error failing condition, got %v", err)
+		}
+	})
+
+	t.Run("nil condition", func(t *testing.T) {
+		var filter *kyte.FilterBuilder
+		_, err := kyte.Filter(kyte.IgnoreGlobalFilters()).And(filter).Build()
+		if !errors.Is(err, kyte.ErrNilFilter) {
+			t.Errorf("Filter.Build should return error %v, got %v", kyte.ErrNilFilter, err)
+		}
+
+		_, err = kyte.Filter(kyte.IgnoreGlobalFilters()).Or(nil).Build()
+		if !errors.Is(err, kyte.ErrNilFilter) {
+			t.Errorf("Filter.Build should return error %v, got %v", kyte.ErrNilFilter, err)
+		}
+	})
+
+	t.Run("global condition", func(t *testing.T) {
+		kyte.ClearGlobalFilters()
+		defer kyte.ClearGlobalFilters()
+		kyte.AddGlobalFilter(textSearch("coffee"))
+
+		globals := kyte.GetGlobalFilters()
+		if len(globals) != 1 || globals[0] != textSearch("coffee") {
+			t.Errorf("GetGlobalFilters should return the custom condition, got %v", globals)
+		}
+
+		q, err := kyte.Filter().Equal("status", "active").Build()
+		if err != nil {
+			t.Errorf("Filter.Build should not return error: %v", err)
+		}
+
+		target := bson.D{
+			{Key: "$text", Value: bson.M{"$search": "coffee"}},
+			{Key: "status", Value: bson.M{"$eq": "active"}},
+		}
+		if !reflect.DeepEqual(q, target) {
+			t.Errorf("Filter.Build should return value %v, got %v", target, q)
+		}
+	})
+}
+
 func TestFilter_Raw(t *testing.T) {
 	t.Parallel()
 
@@ -1913,6 +2455,62 @@ func TestFilter_ToJSON(t *testing.T) {
 	})
 }
 
+func TestFilter_StructTagParser(t *testing.T) {
+	t.Parallel()
+
+	type Account struct {
+		Email string `json:"email"`
+		Name  string
+	}
+
+	t.Run("default parser", func(t *testing.T) {
+		var account Account
+		q, err := kyte.Filter(kyte.Source(&account)).
+			Equal(&account.Email, "joe@kyte.dev").
+			Equal("name", "joe").
+			Build()
+		if err != nil {
+			t.Errorf("Filter.Build should not return error: %v", err)
+		}
+
+		target := bson.D{{Key: "email", Value: bson.M{"$eq": "joe@kyte.dev"}}, {Key: "name", Value: bson.M{"$eq": "joe"}}}
+		if !reflect.DeepEqual(q, target) {
+			t.Errorf("Filter.Build should return value %v, got %v", target, q)
+		}
+	})
+
+	t.Run("custom parser is inherited by sub filters", func(t *testing.T) {
+		var account Account
+		q, err := kyte.Filter(kyte.Source(&account), kyte.StructTagParser(bsoncodec.JSONFallbackStructTagParser)).
+			Or(kyte.Filter().Equal("email", "joe@kyte.dev").Equal(&account.Name, "joe")).
+			Build()
+		if err != nil {
+			t.Errorf("Filter.Build should not return error: %v", err)
+		}
+
+		target := bson.D{{Key: "$or", Value: bson.A{
+			bson.M{"email": bson.M{"$eq": "joe@kyte.dev"}},
+			bson.M{"name": bson.M{"$eq": "joe"}},
+		}}}
+		if !reflect.DeepEqual(q, target) {
+			t.Errorf("Filter.Build should return value %v, got %v", target, q)
+		}
+	})
+
+	t.Run("skipped field", func(t *testing.T) {
+		type Secret struct {
+			Name     string `bson:"name"`
+			Password string `bson:"-"`
+		}
+
+		var secret Secret
+		_, err := kyte.Filter(kyte.Source(&secret)).Equal(&secret.Password, "1234").Build()
+		if !errors.Is(err, kyte.ErrNotValidFieldForQuery) {
+			t.Errorf("Filter.Build should return error %v, got %v", kyte.ErrNotValidFieldForQuery, err)
+		}
+	})
+}
+
 func contains[T comparable](slice []T, item T) bool {
 	for _, s := range slice {
 		if s == item {
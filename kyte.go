@@ -5,15 +5,17 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
-)
 
-var builtinTags = []string{"omitempty", "minsize", "truncate", "inline"}
+	"go.mongodb.org/mongo-driver/bson/bsoncodec"
+)
 
 var (
 	ErrNilSource    = errors.New("source is nil")
 	ErrNotPtrSource = errors.New("source is not a pointer")
 	ErrNotStruct    = errors.New("source is not a pointer of a struct")
 
+	ErrUnsupportedOption = errors.New("option is not supported by the builder")
+
 	ErrEmptyField             = errors.New("field is empty use string or pointer of an source struct field")
 	ErrNilPointerField        = errors.New("field is nil pointer")
 	ErrFieldMustBePtrOrString = errors.New("field must be string or pointer of an source struct field")
@@ -27,6 +29,61 @@ var (
 
 	ErrValueMustBeSlice = errors.New("value must be slice")
 	ErrRegexCannotBeNil = errors.New("regex cannot be nil")
+
+	ErrNilFilter        = errors.New("filter is nil")
+	ErrFieldMustBeArray = errors.New("field must be an array or slice of the source struct")
+
+	ErrNotWithoutOperator = errors.New("not must be followed by a field level operator")
+	ErrNegateEmptyFilter  = errors.New("empty filter cannot be negated")
+
+	ErrNilPipeline           = errors.New("pipeline is nil")
+	ErrLimitMustBePositive   = errors.New("limit must be a positive number")
+	ErrSkipMustNotBeNegative = errors.New("skip must not be a negative number")
+
+	ErrInvalidPopValue     = errors.New("pop value must be 1 or -1")
+	ErrUpdatePathConflict  = errors.New("update paths are conflicting")
+	ErrEmptyUpdateDocument = errors.New("update document is empty")
+
+	ErrProjectionMixed         = errors.New("projection cannot mix inclusion and exclusion except _id")
+	ErrProjectionPathCollision = errors.New("projection paths are colliding")
+
+	ErrDuplicateSortKey = errors.New("sort key is duplicated")
+
+	ErrNilSort               = errors.New("sort is nil")
+	ErrKeysetUnsupportedSort = errors.New("keyset pagination supports only ascending and descending sort keys")
+	ErrKeysetMissingValue    = errors.New("last document does not have a value for the sort key")
+	ErrEmptyPageTokenSecret  = errors.New("page token secret is empty")
+	ErrInvalidPageToken      = errors.New("page token is invalid")
+	ErrPageTokenSortMismatch = errors.New("page token was created with a different sort")
+
+	ErrUnsupportedMatchOperator = errors.New("operator is not supported by match")
+	ErrInvalidMatchOperand      = errors.New("operand of the operator is invalid")
+
+	ErrContradictoryFilter = errors.New("filter conditions contradict each other, no document can match")
+
+	ErrValueTypeMismatch = errors.New("value type does not match the field type")
+
+	ErrInvalidStructTag   = errors.New("struct tag of the source field cannot be parsed")
+	ErrInvalidInlineField = errors.New("inline field must be a struct, a pointer to struct or a map with string keys")
+	ErrDuplicateFieldName = errors.New("source struct has duplicated bson field names at the same level")
+
+	ErrInvalidMapKey = errors.New("map key must not be empty or contain '.' or '$' and must match the key pattern of the field")
+
+	ErrIndexMustNotBeNegative       = errors.New("array index must not be a negative number")
+	ErrPositionalFieldCount         = errors.New("positional path accepts at most one field of the element")
+	ErrInvalidArrayFilterIdentifier = errors.New("array filter identifier must begin with a lowercase letter and contain only letters and digits")
+	ErrMissingArrayFilter           = errors.New("array filter is not found for the identifier")
+	ErrUnusedArrayFilter            = errors.New("array filter is not used in the update")
+
+	ErrInvalidParam             = errors.New("parameter must be in the form of field or field[operator]")
+	ErrUnsupportedParamOperator = errors.New("operator of the parameter is not supported")
+	ErrOperatorNotAllowed       = errors.New("operator is not allowed")
+	ErrFieldNotAllowed          = errors.New("field is not allowed")
+	ErrInvalidFieldPath         = errors.New("field path must not have empty segments or segments beginning with '$'")
+	ErrInvalidParamValue        = errors.New("value of the parameter is invalid")
+
+	ErrInvalidSyntax         = errors.New("query expression has invalid syntax")
+	ErrUnsupportedExpression = errors.New("query expression is not supported")
 )
 
 const (
@@ -34,6 +91,8 @@ const (
 	UnderScoreID = "_id"
 	// Alias for mongo _id field with dollar sign prefix
 	UnderScoreIDWithDollar = "$_id"
+	// Alias for the array element itself, it can be used as a field inside ElemMatch for arrays of scalar values
+	Elem = "$elem"
 )
 
 type Options struct {
@@ -49,10 +108,101 @@ type Options struct {
 	//
 	// Default: false
 	ignoreGlobalFilters bool
+
+	// Optimize when set to true, the query will be simplified before Build returns it.
+	//
+	// Default: false
+	optimize bool
+
+	// TagParser is used to find the bson names of the source struct fields, it should be the parser of the struct codec that encodes the source.
+	//
+	// Default: bsoncodec.DefaultStructTagParser
+	tagParser bsoncodec.StructTagParser
+
+	// MaxDepth is the maximum number of the path segments of the source struct fields, the deeper fields are not valid for query.
+	//
+	// Default: 0, there is no limit and the recursive types are not expanded again
+	maxDepth int
+
+	// AllowedOperators are the operators that can be used by the parsed filters such as FromURLValues.
+	//
+	// Default: all the supported operators
+	allowedOperators []string
+
+	// AllowedFields are the fields that can be used by the parsed filters and sorts.
+	//
+	// Default: all the fields
+	allowedFields []any
+
+	// IgnoredParams are the parameters that are skipped by FromURLValues, such as the pagination parameters.
+	//
+	// Default: sort
+	ignoredParams []string
+
+	// ZeroFields are the fields whose zero values are matched by FromExample.
+	//
+	// Default: the zero values are skipped
+	zeroFields []any
+
+	// SliceAsIn when set to true, FromExample matches the slice fields with $in instead of the whole array.
+	//
+	// Default: false
+	sliceAsIn bool
+
+	// IgnoreCase when set to true, FromExample matches the string fields case insensitively.
+	//
+	// Default: false
+	ignoreCase bool
+
+	// scoped are the options that are given among the ones that only some of the builders use.
+	scoped scopedOption
 }
 
 type OptionFunc func(*Options)
 
+/*
+scopedOption is a set of the options that are used only by the parsers or by FromExample, the other builders
+reject them instead of silently ignoring them.
+*/
+type scopedOption uint8
+
+const (
+	allowOperatorsOption scopedOption = 1 << iota
+	allowFieldsOption
+	ignoreParamsOption
+	zeroFieldsOption
+	sliceAsInOption
+	ignoreCaseOption
+)
+
+// scopedOptionNames are the names of the option functions in the order of the scopedOption bits.
+var scopedOptionNames = []string{"AllowOperators", "AllowFields", "IgnoreParams", "ZeroFields", "SliceAsIn", "IgnoreCase"}
+
+func newOptions(opts []OptionFunc) *Options {
+	options := &Options{validateField: true}
+	for _, opt := range opts {
+		opt(options)
+	}
+	return options
+}
+
+/*
+unsupported returns ErrUnsupportedOption with the names of the given scoped options that are not in the supported set.
+*/
+func (o *Options) unsupported(supported scopedOption) error {
+	var names []string
+	for i, name := range scopedOptionNames {
+		if o.scoped&^supported&(1<<i) != 0 {
+			names = append(names, name)
+		}
+	}
+
+	if len(names) == 0 {
+		return nil
+	}
+	return errors.Join(ErrUnsupportedOption, fmt.Errorf("options: %s", strings.Join(names, ", ")))
+}
+
 func ValidateField(validateField bool) OptionFunc {
 	return func(o *Options) {
 		o.validateField = validateField
@@ -74,128 +224,94 @@ func IgnoreGlobalFilters() OptionFunc {
 	}
 }
 
+/*
+StructTagParser is an option function that sets the parser used to find the bson names of the source struct fields.
+It should be the same parser as the struct codec of the mongo client, so the paths are the ones the driver writes.
+
+	kyte.Filter(kyte.Source(&user), kyte.StructTagParser(bsoncodec.JSONFallbackStructTagParser))
+
+The schemas are cached per source type and parser, except for the parsers that are not comparable such as functions,
+the source is reflected on every use with those parsers.
+*/
+func StructTagParser(parser bsoncodec.StructTagParser) OptionFunc {
+	return func(o *Options) {
+		o.tagParser = parser
+	}
+}
+
+/*
+MaxDepth is an option function that limits the depth of the source struct fields, for example the depth of "address.geo.lat" is 3.
+The fields of the recursive types are expanded until the depth is reached, without a limit they are expanded only once.
+
+	type Category struct {
+		Name   string    `bson:"name"`
+		Parent *Category `bson:"parent"`
+	}
+
+	kyte.Filter(kyte.Source(&category), kyte.MaxDepth(3)).
+		Equal("parent.parent.name", "books")
+*/
+func MaxDepth(depth int) OptionFunc {
+	return func(o *Options) {
+		o.maxDepth = depth
+	}
+}
+
 type kyte struct {
 	source     any
-	fields     map[any]string
+	base       reflect.Value
+	schema     *typeSchema
 	fieldNames []string
+	fieldTypes map[string]reflect.Type
 	err        error
 	checkField bool
+	options    schemaOptions
 }
 
 func newKyte(source any, checkField bool) *kyte {
-	if source == nil {
-		return &kyte{}
+	return newKyteWithOptions(&Options{source: source, validateField: checkField})
+}
+
+func newKyteWithOptions(options *Options) *kyte {
+	schemaOptions := schemaOptions{parser: options.tagParser, maxDepth: options.maxDepth}
+	if options.source == nil {
+		return &kyte{options: schemaOptions}
 	}
-	kyte := &kyte{fields: make(map[any]string), checkField: checkField}
-	kyte.setSourceAndPrepareFields(source)
+	kyte := &kyte{checkField: options.validateField, options: schemaOptions}
+	kyte.setSourceAndPrepareFields(options.source)
 	return kyte
 }
 
-// TODO refactor this function
+/*
+setSourceAndPrepareFields sets the source and its fields from the schema cache, the struct is reflected only once per type.
+*/
 func (k *kyte) setSourceAndPrepareFields(source any) {
 	k.source = source
-	k.fields = make(map[any]string)
+	k.schema = nil
 	k.fieldNames = []string{}
+	k.fieldTypes = nil
 
 	if reflect.ValueOf(source).Kind() != reflect.Ptr {
 		k.err = ErrNotPtrSource
 		return
 	}
 
-	if reflect.ValueOf(source).Kind() == reflect.Ptr && reflect.ValueOf(source).Elem().Kind() != reflect.Struct {
+	if reflect.ValueOf(source).Elem().Kind() != reflect.Struct {
 		k.err = ErrNotStruct
 		return
 	}
 
-	k.source = source
-	v := reflect.ValueOf(source).Elem()
-	for i := 0; i < v.NumField(); i++ {
-		field := v.Type().Field(i)
-		fieldValue := v.Field(i)
-		kind := fieldValue.Kind()
-
-		if !field.IsExported() {
-			continue
-		}
-
-		bsonTag := getBsonTag(field)
-		if bsonTag == "" {
-			continue
-		}
-
-		var addr any
-		if fieldValue.CanAddr() && fieldValue.Addr().Interface() != nil {
-			addr = fieldValue.Addr().Interface()
-		}
-
-		if kind == reflect.Slice {
-			sliceType := fieldValue.Type().Elem()
-			if sliceType.Kind() == reflect.Ptr {
-				sliceType = sliceType.Elem()
-			}
-
-			if sliceType.Kind() == reflect.Struct {
-				if fieldValue.Len() > 0 {
-					firstElem := fieldValue.Index(0)
-					firstElemType := firstElem.Type()
-					if firstElemType.Kind() == reflect.Ptr {
-						firstElem = firstElem.Elem()
-					}
-
-					getSubStructFields(firstElem, bsonTag, k.fields)
-				} else {
-					sliceElem := reflect.New(sliceType).Elem()
-					getSubStructFields(sliceElem, bsonTag, k.fields)
-				}
-			}
-
-		}
-
-		if kind == reflect.Ptr {
-			underlyingValue := reflect.New(fieldValue.Type().Elem()).Elem()
-			underlyingKind := underlyingValue.Kind()
-			if underlyingKind == reflect.Struct {
-				getSubStructFields(fieldValue.Elem(), bsonTag, k.fields)
-			}
-
-			if underlyingKind == reflect.Slice {
-				underlyingValue = reflect.New(underlyingValue.Type().Elem()).Elem()
-				underlyingType := underlyingValue.Type()
-				underlyingKind = underlyingValue.Kind()
-				if underlyingKind == reflect.Ptr {
-					underlyingType = underlyingType.Elem()
-				}
-
-				if underlyingType.Kind() == reflect.Struct {
-					if !fieldValue.IsZero() && fieldValue.Elem().Len() > 0 {
-						firstElem := fieldValue.Elem().Index(0)
-						firstElemType := firstElem.Type()
-						if firstElemType.Kind() == reflect.Ptr {
-							firstElem = firstElem.Elem()
-						}
-						getSubStructFields(firstElem, bsonTag, k.fields)
-					} else {
-						sliceElem := reflect.New(underlyingType).Elem()
-						getSubStructFields(sliceElem, bsonTag, k.fields)
-					}
-				}
-
-			}
-
-		}
-
-		if field.Type.Kind() == reflect.Struct {
-			getSubStructFields(v.Field(i), bsonTag, k.fields)
-		}
-
-		if bsonTag != "" && addr != nil {
-			k.fields[addr] = bsonTag
-		}
+	schema, err := getSchema(reflect.ValueOf(source).Elem().Type(), k.options)
+	if err != nil {
+		k.err = err
+		return
 	}
 
-	for _, v := range k.fields {
-		k.fieldNames = append(k.fieldNames, v)
-	}
+	k.base = reflect.ValueOf(source).Elem()
+	k.schema = schema
+	// the names are shared by the cache, appending to them must not write to the cached array
+	k.fieldNames = k.schema.names[:len(k.schema.names):len(k.schema.names)]
+	k.fieldTypes = k.schema.types
 }
 
 func (k *kyte) setError(err error) {
@@ -209,6 +325,21 @@ type operation struct {
 	field           any
 	value           any
 	isFieldRequired bool
+	negate          bool
+}
+
+/*
+fieldRef is implemented by the typed field handles such as [Field], they are resolved to their path before they are validated.
+*/
+type fieldRef interface {
+	fieldPath(k *kyte) (string, error)
+}
+
+func (k *kyte) resolveFieldRef(field any) (any, error) {
+	if ref, ok := field.(fieldRef); ok {
+		return ref.fieldPath(k)
+	}
+	return field, nil
 }
 
 func (k *kyte) validate(opt *operation) error {
@@ -216,6 +347,12 @@ func (k *kyte) validate(opt *operation) error {
 		return k.err
 	}
 
+	field, err := k.resolveFieldRef(opt.field)
+	if err != nil {
+		return err
+	}
+	opt.field = field
+
 	if opt.isFieldRequired && opt.field == nil {
 		return ErrNilField
 	}
@@ -231,7 +368,7 @@ func (k *kyte) validate(opt *operation) error {
 		}
 	}
 
-	if opt.isFieldRequired && (k.checkField && k.fields != nil) {
+	if opt.isFieldRequired && (k.checkField && k.schema != nil) {
 		if err := k.isFieldValid(opt.field); err != nil {
 			return err
 		}
@@ -240,6 +377,31 @@ func (k *kyte) validate(opt *operation) error {
 	return nil
 }
 
+/*
+resolveField validates the given field and returns its name, it is used by the builders that resolve their fields immediately.
+*/
+func (k *kyte) resolveField(field any) (string, error) {
+	opt := &operation{field: field, isFieldRequired: true}
+	if err := k.validate(opt); err != nil {
+		return "", err
+	}
+
+	return k.getFieldName(opt.field)
+}
+
+/*
+checkFieldPath returns ErrInvalidFieldPath if the path has an empty segment or a segment beginning with '$',
+it is used for the paths that are not checked against the source such as the untrusted input of the parsers.
+*/
+func checkFieldPath(path string) error {
+	for _, segment := range strings.Split(path, ".") {
+		if segment == "" || strings.HasPrefix(segment, "$") {
+			return errors.Join(ErrInvalidFieldPath, fmt.Errorf("field: %s", path))
+		}
+	}
+	return nil
+}
+
 func (k *kyte) isFieldValid(field any) error {
 	if k.hasErrors() {
 		return k.err
@@ -254,14 +416,22 @@ func (k *kyte) isFieldValid(field any) error {
 
 	ok := false
 	if fieldType.Kind() == reflect.Ptr {
-		_, ok = k.fields[field]
+		_, ok = k.lookupField(field)
 	}
 
-	if !ok && !contains(k.fieldNames, fieldName) {
-		return errors.Join(ErrNotValidFieldForQuery, fmt.Errorf("field: %s You can ignore this error by setting checkField to false", fieldName))
+	if ok || contains(k.fieldNames, fieldName) || k.schema.inInlineMap(fieldName) {
+		return nil
 	}
 
-	return nil
+	if _, matched, err := k.schema.mapField(fieldName); matched {
+		return err
+	}
+
+	if normalized, ok := k.positionalField(fieldName); ok {
+		return k.isFieldValid(normalized)
+	}
+
+	return errors.Join(ErrNotValidFieldForQuery, fmt.Errorf("field: %s You can ignore this error by setting checkField to false", fieldName))
 }
 
 func (k *kyte) hasErrors() bool {
@@ -269,16 +439,21 @@ func (k *kyte) hasErrors() bool {
 }
 
 func (k *kyte) getFieldName(field any) (string, error) {
+	field, err := k.resolveFieldRef(field)
+	if err != nil {
+		return "", err
+	}
+
 	if reflect.TypeOf(field).Kind() == reflect.String {
 		return field.(string), nil
 	}
 
-	if reflect.TypeOf(field).Kind() == reflect.Ptr && k.fields == nil {
+	if reflect.TypeOf(field).Kind() == reflect.Ptr && k.schema == nil {
 		return "", ErrFieldMustBeString
 	}
 
 	if reflect.TypeOf(field).Kind() == reflect.Ptr {
-		fieldName, ok := k.fields[field]
+		fieldName, ok := k.lookupField(field)
 		if !ok {
 			return "", ErrNotValidFieldForQuery
 		}
@@ -288,49 +463,84 @@ func (k *kyte) getFieldName(field any) (string, error) {
 	return "", ErrFieldMustBePtrOrString
 }
 
-func getBsonTag(field reflect.StructField) string {
-	bsonTag := field.Tag.Get("bson")
-	if bsonTag == "" || bsonTag == "-" {
-		return ""
+/*
+elemSource returns a pointer of the array element of the given field that can be used as a source.
+If the array is not empty its first element is used, so pointers of the first element can still be used as fields.
+It returns nil if the element is not a struct.
+*/
+func (k *kyte) elemSource(field any) (any, error) {
+	field, err := k.resolveFieldRef(field)
+	if err != nil {
+		return nil, err
 	}
 
-	splitBsonTag := strings.Split(bsonTag, ",")
+	var value reflect.Value
+	switch reflect.TypeOf(field).Kind() {
+	case reflect.Ptr:
+		value = reflect.ValueOf(field).Elem()
+	case reflect.String:
+		value, _ = k.valueOf(field.(string))
+	}
 
-	for _, tag := range splitBsonTag {
-		if !contains(builtinTags, tag) || tag == "-" {
-			return tag
-		}
+	if !value.IsValid() {
+		return nil, nil
 	}
 
-	return ""
-}
+	for value.Kind() == reflect.Ptr && !value.IsNil() {
+		value = value.Elem()
+	}
 
-func getSubStructFields(s reflect.Value, parentPrefix string, fields map[any]string) {
-	if parentPrefix != "" {
-		parentPrefix += "."
+	valueType := value.Type()
+	for valueType.Kind() == reflect.Ptr {
+		valueType = valueType.Elem()
 	}
 
-	if s.Kind() != reflect.Struct {
-		return
+	if valueType.Kind() != reflect.Slice && valueType.Kind() != reflect.Array {
+		return nil, ErrFieldMustBeArray
 	}
 
-	for i := 0; i < s.NumField(); i++ {
-		field := s.Type().Field(i)
-		fieldValue := s.Field(i)
-		if !field.IsExported() {
-			continue
-		}
+	elemType := valueType.Elem()
+	if elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
 
-		if field.Type.Kind() == reflect.Struct {
-			parentPrefix := getBsonTag(field)
-			getSubStructFields(s.Field(i), parentPrefix+".", fields)
+	if elemType.Kind() != reflect.Struct {
+		return nil, nil
+	}
+
+	if value.Kind() != reflect.Ptr && value.Len() > 0 {
+		firstElem := value.Index(0)
+		if firstElem.Kind() == reflect.Ptr && !firstElem.IsNil() {
+			firstElem = firstElem.Elem()
 		}
 
-		bsonTag := getBsonTag(field)
-		if bsonTag != "" && fieldValue.CanAddr() {
-			fields[fieldValue.Addr().Interface()] = parentPrefix + bsonTag
+		if firstElem.Kind() == reflect.Struct && firstElem.CanAddr() {
+			return firstElem.Addr().Interface(), nil
 		}
 	}
+
+	return reflect.New(elemType).Interface(), nil
+}
+
+/*
+parseStructTags parses the bson tags of the field like the mongo driver does, the name of a field without a bson tag is its lowercased name.
+The unexported and skipped fields are reported as not encoded.
+*/
+func parseStructTags(parser bsoncodec.StructTagParser, field reflect.StructField) (bsoncodec.StructTags, bool, error) {
+	if !field.IsExported() {
+		return bsoncodec.StructTags{}, false, nil
+	}
+
+	if parser == nil {
+		parser = bsoncodec.DefaultStructTagParser
+	}
+
+	tags, err := parser.ParseStructTags(field)
+	if err != nil {
+		return tags, false, errors.Join(ErrInvalidStructTag, fmt.Errorf("field: %s %w", field.Name, err))
+	}
+
+	return tags, !tags.Skip, nil
 }
 
 func contains[T comparable](slice []T, item T) bool {
@@ -2,7 +2,12 @@ package kyte
 
 import (
 	"errors"
+	"fmt"
+	"reflect"
+	"sync"
 	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/bsoncodec"
 )
 
 type TestTodo struct {
@@ -47,17 +52,22 @@ type TestWithNestedStructWithPointer struct {
 }
 
 func testKyteFieldAndSource(t *testing.T, kyte *kyte, fields map[any]string, fieldCount int) {
-	if len(kyte.fields) != fieldCount {
-		t.Errorf("kyte.fields should be %v but got %v", fieldCount, len(kyte.fields))
-	}
-
 	if len(kyte.fieldNames) != fieldCount {
 		t.Errorf("kyte.fieldNames should be %v but got %v", fieldCount, len(kyte.fieldNames))
 	}
 
+	if len(kyte.fieldTypes) != fieldCount {
+		t.Errorf("kyte.fieldTypes should be %v but got %v", fieldCount, len(kyte.fieldTypes))
+	}
+
 	for ptr, field := range fields {
-		if _, ok := kyte.fields[ptr]; !ok {
-			t.Errorf("kyte.fields should have %v field", field)
+		path, ok := kyte.lookupField(ptr)
+		if !ok {
+			t.Errorf("kyte should resolve %v field", field)
+		}
+
+		if ok && path != field {
+			t.Errorf("kyte should resolve %v field but got %v", field, path)
 		}
 	}
 }
@@ -331,7 +341,7 @@ func TestNewKyte(t *testing.T) {
 		testKyteFieldAndSource(t, kyte, fields, 5)
 	})
 
-	t.Run("ignore field if does not have bson tag", func(t *testing.T) {
+	t.Run("use lowercased name if field does not have bson tag", func(t *testing.T) {
 		type TestAnonymousWithSlicePointer struct {
 			Name string    `bson:"name"`
 			Todo *TestTodo `bson:"todo"`
@@ -346,12 +356,79 @@ func TestNewKyte(t *testing.T) {
 		fields := map[any]string{
 			&source.Name: "name",
 			&source.Todo: "todo",
+			&source.Time: "time",
 			&todo.ID:     "todo.id",
 			&todo.Name:   "todo.name",
 		}
 
 		kyte := newKyte(source, true)
-		testKyteFieldAndSource(t, kyte, fields, 5)
+		testKyteFieldAndSource(t, kyte, fields, 6)
+	})
+
+	t.Run("use driver naming rules for bson tags", func(t *testing.T) {
+		type Audit struct {
+			CreatedBy string `bson:"created_by"`
+		}
+
+		type TestDriverNaming struct {
+			UserName string `bson:",omitempty"`
+			Age      int    `bson:"age,omitempty,minsize"`
+			Skipped  string `bson:"-"`
+			JSONName string `json:"json_name"`
+			Audit    Audit  `bson:",inline"`
+			private  string
+		}
+
+		source := &TestDriverNaming{}
+		fields := map[any]string{
+			&source.UserName:        "username",
+			&source.Age:             "age",
+			&source.JSONName:        "jsonname",
+			&source.Audit.CreatedBy: "created_by",
+		}
+
+		kyte := newKyte(source, true)
+		testKyteFieldAndSource(t, kyte, fields, 4)
+
+		if _, ok := kyte.lookupField(&source.Skipped); ok {
+			t.Errorf("kyte should not resolve the skipped field")
+		}
+
+		if _, ok := kyte.lookupField(&source.private); ok {
+			t.Errorf("kyte should not resolve the unexported field")
+		}
+	})
+
+	t.Run("use the given struct tag parser", func(t *testing.T) {
+		type TestJSONNaming struct {
+			Name     string `json:"name"`
+			Email    string `bson:"mail" json:"email"`
+			Password string `json:"-"`
+		}
+
+		source := &TestJSONNaming{}
+		fields := map[any]string{
+			&source.Name:  "name",
+			&source.Email: "mail",
+		}
+
+		kyte := newKyteWithOptions(&Options{
+			source:        source,
+			validateField: true,
+			tagParser:     bsoncodec.JSONFallbackStructTagParser,
+		})
+		testKyteFieldAndSource(t, kyte, fields, 2)
+	})
+
+	t.Run("struct tag parser error", func(t *testing.T) {
+		parser := bsoncodec.StructTagParserFunc(func(field reflect.StructField) (bsoncodec.StructTags, error) {
+			return bsoncodec.StructTags{}, errors.New("invalid tag")
+		})
+
+		kyte := newKyteWithOptions(&Options{source: &TestTodo{}, validateField: true, tagParser: parser})
+		if !errors.Is(kyte.err, ErrInvalidStructTag) {
+			t.Errorf("kyte.err should be %v but got %v", ErrInvalidStructTag, kyte.err)
+		}
 	})
 }
 
@@ -571,3 +648,250 @@ func TestKyteGetFieldName(t *testing.T) {
 		}
 	})
 }
+
+func TestKyteSchemaCache(t *testing.T) {
+	t.Parallel()
+
+	t.Run("same type resolves against each instance", func(t *testing.T) {
+		first := &TestArrayStruct{Todos: []TestTodo{{}}}
+		second := &TestArrayStruct{Todos: []TestTodo{{}}}
+		firstKyte := newKyte(first, true)
+		secondKyte := newKyte(second, true)
+
+		if firstKyte.schema != secondKyte.schema {
+			t.Errorf("kyte.schema should be shared between the sources of the same type")
+		}
+
+		if _, ok := firstKyte.lookupField(&second.Username); ok {
+			t.Errorf("kyte should not resolve the field of another instance")
+		}
+
+		field, err := secondKyte.getFieldName(&second.Todos[0].Name)
+		if err != nil {
+			t.Errorf("kyte.getFieldName() should not return error but got %v", err)
+		}
+
+		if field != "todos.name" {
+			t.Errorf("kyte.getFieldName() should return todos.name but got %v", field)
+		}
+	})
+
+	t.Run("concurrent sources", func(t *testing.T) {
+		var wg sync.WaitGroup
+		errs := make(chan error, 50)
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				source := &TestWithNestedStruct{}
+				field, err := newKyte(source, true).getFieldName(&source.Todo.Name)
+				if err == nil && field != "todo.name" {
+					err = fmt.Errorf("expected todo.name but got %v", field)
+				}
+				errs <- err
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		for err := range errs {
+			if err != nil {
+				t.Errorf("kyte.getFieldName() should not return error but got %v", err)
+			}
+		}
+	})
+}
+
+func TestKyteInlineFields(t *testing.T) {
+	t.Parallel()
+
+	type BaseModel struct {
+		ID        string `bson:"_id"`
+		CreatedAt string `bson:"createdAt"`
+	}
+
+	type Audit struct {
+		UpdatedBy string `bson:"updatedBy"`
+		CreatedAt string `bson:"createdAt"`
+	}
+
+	t.Run("embedded struct and pointer", func(t *testing.T) {
+		type Owner struct {
+			OwnerID string `bson:"ownerId"`
+			Name    string `bson:"name"`
+		}
+
+		type TestInline struct {
+			BaseModel `bson:",inline"`
+			*Owner    `bson:",inline"`
+			Name      string `bson:"name"`
+		}
+
+		source := &TestInline{Owner: &Owner{}}
+		fields := map[any]string{
+			&source.ID:        "_id",
+			&source.CreatedAt: "createdAt",
+			&source.OwnerID:   "ownerId",
+			&source.Name:      "name",
+		}
+
+		kyte := newKyte(source, true)
+		testKyteFieldAndSource(t, kyte, fields, 4)
+
+		if _, ok := kyte.lookupField(&source.Owner.Name); ok {
+			t.Errorf("kyte should not resolve the dominated field")
+		}
+	})
+
+	t.Run("shallower field dominates", func(t *testing.T) {
+		type TestInline struct {
+			Audit     Audit  `bson:",inline"`
+			UpdatedBy string `bson:"updatedBy"`
+		}
+
+		source := &TestInline{}
+		fields := map[any]string{
+			&source.UpdatedBy:       "updatedBy",
+			&source.Audit.CreatedAt: "createdAt",
+		}
+
+		kyte := newKyte(source, true)
+		testKyteFieldAndSource(t, kyte, fields, 2)
+	})
+
+	t.Run("inline map", func(t *testing.T) {
+		type TestInline struct {
+			Name  string         `bson:"name"`
+			Extra map[string]any `bson:",inline"`
+		}
+
+		kyte := newKyte(&TestInline{}, true)
+		if err := kyte.isFieldValid("color"); err != nil {
+			t.Errorf("kyte.isFieldValid() should not return error but got %v", err)
+		}
+
+		if len(kyte.fieldNames) != 1 {
+			t.Errorf("kyte.fieldNames should be 1 but got %v", len(kyte.fieldNames))
+		}
+	})
+
+	t.Run("duplicated field names", func(t *testing.T) {
+		type TestInline struct {
+			BaseModel BaseModel `bson:",inline"`
+			Audit     Audit     `bson:",inline"`
+		}
+
+		kyte := newKyte(&TestInline{}, true)
+		if !errors.Is(kyte.err, ErrDuplicateFieldName) {
+			t.Errorf("kyte.err should be %v but got %v", ErrDuplicateFieldName, kyte.err)
+		}
+	})
+
+	t.Run("invalid inline fields", func(t *testing.T) {
+		type TestInlineString struct {
+			Name string `bson:",inline"`
+		}
+
+		type TestInlineMaps struct {
+			First  map[string]any `bson:",inline"`
+			Second map[string]any `bson:",inline"`
+		}
+
+		for _, source := range []any{&TestInlineString{}, &TestInlineMaps{}} {
+			kyte := newKyte(source, true)
+			if !errors.Is(kyte.err, ErrInvalidInlineField) {
+				t.Errorf("kyte.err should be %v but got %v", ErrInvalidInlineField, kyte.err)
+			}
+		}
+	})
+}
+
+func TestKyteDeepFields(t *testing.T) {
+	t.Parallel()
+
+	type Item struct {
+		Sku string `bson:"sku"`
+	}
+
+	type Order struct {
+		ID    string  `bson:"id"`
+		Items []*Item `bson:"items"`
+	}
+
+	type Point struct {
+		X int `bson:"x"`
+		Y int `bson:"y"`
+	}
+
+	type Label struct {
+		Text string `bson:"text"`
+	}
+
+	type TestDeep struct {
+		Orders []Order           `bson:"orders"`
+		Points [2]Point          `bson:"points"`
+		Attrs  map[string]string `bson:"attrs"`
+		Labels map[string]Label  `bson:"labels"`
+	}
+
+	t.Run("pointers slices arrays and maps", func(t *testing.T) {
+		source := &TestDeep{
+			Orders: []Order{{Items: []*Item{{}}}, {Items: []*Item{{}, {}}}},
+		}
+		fields := map[any]string{
+			&source.Orders:                 "orders",
+			&source.Orders[1].ID:           "orders.id",
+			&source.Orders[1].Items[1].Sku: "orders.items.sku",
+			&source.Points[1].Y:            "points.y",
+			&source.Attrs:                  "attrs",
+		}
+
+		kyte := newKyte(source, true)
+		testKyteFieldAndSource(t, kyte, fields, 12)
+
+		for _, path := range []string{"attrs.*", "labels.*", "labels.*.text"} {
+			if err := kyte.isFieldValid(path); err != nil {
+				t.Errorf("kyte.isFieldValid() should not return error for %v but got %v", path, err)
+			}
+		}
+	})
+
+	t.Run("recursive type", func(t *testing.T) {
+		type Category struct {
+			Name     string      `bson:"name"`
+			Parent   *Category   `bson:"parent"`
+			Children []*Category `bson:"children"`
+		}
+
+		source := &Category{}
+		kyte := newKyte(source, true)
+		testKyteFieldAndSource(t, kyte, map[any]string{&source.Parent: "parent"}, 3)
+
+		kyte = newKyteWithOptions(&Options{source: source, validateField: true, maxDepth: 3})
+		if err := kyte.isFieldValid("parent.children.name"); err != nil {
+			t.Errorf("kyte.isFieldValid() should not return error but got %v", err)
+		}
+
+		if err := kyte.isFieldValid("parent.parent.parent.name"); !errors.Is(err, ErrNotValidFieldForQuery) {
+			t.Errorf("kyte.isFieldValid() should return error %v but got %v", ErrNotValidFieldForQuery, err)
+		}
+	})
+
+	t.Run("max depth", func(t *testing.T) {
+		type TestDepth struct {
+			Name  string   `bson:"name"`
+			Order Order    `bson:"order"`
+			Items []Item   `bson:"items"`
+			Tags  []string `bson:"tags"`
+		}
+
+		kyte := newKyteWithOptions(&Options{source: &TestDepth{}, validateField: true, maxDepth: 1})
+		if len(kyte.fieldNames) != 4 {
+			t.Errorf("kyte.fieldNames should be 4 but got %v", len(kyte.fieldNames))
+		}
+
+		if kyte.schema == newKyte(&TestDepth{}, true).schema {
+			t.Errorf("kyte.schema should be cached per max depth")
+		}
+	})
+}
@@ -0,0 +1,127 @@
+package kyte
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+/*
+MapKeyPath is a reference to a key of a map field, it is created by [MapKey] and resolved to the dotted path of the key
+by the builders, so it can be stored and passed wherever a field is accepted.
+*/
+type MapKeyPath struct {
+	field any
+	key   string
+}
+
+/*
+MapKey returns a reference to the key of the given map field, it can be used as a field in all builders. The key must not be empty
+or contain '.' or '$', so a user provided key cannot change the path or turn into an operator.
+
+	type Product struct {
+		Attrs map[string]string `bson:"attrs" kyte:"keys=^[a-z_]+$"`
+	}
+
+	kyte.Filter(kyte.Source(&product)).
+		Equal(kyte.MapKey(&product.Attrs, "color"), "red") // {"attrs.color": {"$eq": "red"}}
+
+The key is also validated against the key pattern declared with the kyte tag of the field.
+*/
+func MapKey(field any, key string) MapKeyPath {
+	return MapKeyPath{field: field, key: key}
+}
+
+func (m MapKeyPath) fieldPath(k *kyte) (string, error) {
+	if m.key == "" || strings.ContainsAny(m.key, ".$") {
+		return "", errors.Join(ErrInvalidMapKey, fmt.Errorf("key: %q", m.key))
+	}
+
+	if m.field == nil {
+		return "", ErrNilField
+	}
+
+	path, err := k.getFieldName(m.field)
+	if err != nil {
+		return "", err
+	}
+
+	return path + "." + m.key, nil
+}
+
+/*
+mapField returns the wildcard path that matches the path, such as attrs.* for attrs.color. The keys of the path are checked
+against the key patterns of their map fields.
+*/
+func (s *typeSchema) mapField(path string) (string, bool, error) {
+	segments := strings.Split(path, ".")
+	for _, wildcardPath := range s.wildcards {
+		wildcardSegments := strings.Split(wildcardPath, ".")
+		if !matchSegments(wildcardSegments, segments) {
+			continue
+		}
+
+		for i, segment := range wildcardSegments {
+			if segment != wildcard {
+				continue
+			}
+
+			regex := s.mapKeys[strings.Join(wildcardSegments[:i+1], ".")]
+			if regex != nil && !regex.MatchString(segments[i]) {
+				return wildcardPath, true, errors.Join(ErrInvalidMapKey, fmt.Errorf("field: %s key: %q pattern: %s", path, segments[i], regex))
+			}
+		}
+
+		return wildcardPath, true, nil
+	}
+
+	return "", false, nil
+}
+
+func matchSegments(pattern []string, segments []string) bool {
+	if len(pattern) != len(segments) {
+		return false
+	}
+
+	for i, segment := range pattern {
+		if segment != wildcard && segment != segments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func hasWildcard(path string) bool {
+	for _, segment := range strings.Split(path, ".") {
+		if segment == wildcard {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+fieldType returns the type of the source field at the given path, the keys of the map fields are resolved to their value type
+and the positional segments of the arrays are resolved to the fields of their elements.
+*/
+func (k *kyte) fieldType(path string) (reflect.Type, bool) {
+	if t, ok := k.fieldTypes[path]; ok {
+		return t, true
+	}
+
+	if k.schema == nil {
+		return nil, false
+	}
+
+	if wildcardPath, ok, err := k.schema.mapField(path); ok {
+		t, ok := k.fieldTypes[wildcardPath]
+		return t, ok && err == nil
+	}
+
+	if normalized, ok := k.positionalField(path); ok {
+		return k.fieldType(normalized)
+	}
+
+	return nil, false
+}
@@ -0,0 +1,106 @@
+package kyte_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aaydin-tr/kyte"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type Label struct {
+	Text string `bson:"text"`
+}
+
+type Product struct {
+	Name   string            `bson:"name"`
+	Attrs  map[string]string `bson:"attrs" kyte:"keys=^[a-z_]+$"`
+	Labels map[string]Label  `bson:"labels"`
+}
+
+func TestMapKey(t *testing.T) {
+	t.Parallel()
+
+	t.Run("map key path", func(t *testing.T) {
+		var product Product
+		q, err := kyte.Filter(kyte.Source(&product)).
+			Equal(kyte.MapKey(&product.Attrs, "color"), "red").
+			Equal("attrs.size", "xl").
+			Equal("labels.en.text", "shirt").
+			Build()
+		if err != nil {
+			t.Errorf("Filter.Build should not return error: %v", err)
+		}
+
+		target := bson.D{
+			{Key: "attrs.color", Value: bson.M{"$eq": "red"}},
+			{Key: "attrs.size", Value: bson.M{"$eq": "xl"}},
+			{Key: "labels.en.text", Value: bson.M{"$eq": "shirt"}},
+		}
+		if !reflect.DeepEqual(q, target) {
+			t.Errorf("Filter.Build should return value %v, got %v", target, q)
+		}
+	})
+
+	t.Run("map key without source", func(t *testing.T) {
+		var color kyte.MapKeyPath = kyte.MapKey("attrs", "color")
+		q, err := kyte.Filter().Exists(color, true).Build()
+		if err != nil {
+			t.Errorf("Filter.Build should not return error: %v", err)
+		}
+
+		target := bson.D{{Key: "attrs.color", Value: bson.M{"$exists": true}}}
+		if !reflect.DeepEqual(q, target) {
+			t.Errorf("Filter.Build should return value %v, got %v", target, q)
+		}
+	})
+
+	t.Run("invalid keys", func(t *testing.T) {
+		var product Product
+		for _, key := range []string{"", "color.name", "$where", "Color"} {
+			_, err := kyte.Filter(kyte.Source(&product)).Equal(kyte.MapKey(&product.Attrs, key), "red").Build()
+			if !errors.Is(err, kyte.ErrInvalidMapKey) {
+				t.Errorf("Filter.Build should return error %v for key %q, got %v", kyte.ErrInvalidMapKey, key, err)
+			}
+		}
+
+		_, err := kyte.Filter(kyte.Source(&product)).Equal("attrs.Color", "red").Build()
+		if !errors.Is(err, kyte.ErrInvalidMapKey) {
+			t.Errorf("Filter.Build should return error %v, got %v", kyte.ErrInvalidMapKey, err)
+		}
+	})
+
+	t.Run("field not in map value", func(t *testing.T) {
+		var product Product
+		_, err := kyte.Filter(kyte.Source(&product)).Equal("labels.en.color", "red").Build()
+		if !errors.Is(err, kyte.ErrNotValidFieldForQuery) {
+			t.Errorf("Filter.Build should return error %v, got %v", kyte.ErrNotValidFieldForQuery, err)
+		}
+	})
+
+	t.Run("map value type", func(t *testing.T) {
+		var product Product
+		_, err := kyte.Filter(kyte.Source(&product)).Equal(kyte.MapKey(&product.Attrs, "size"), 42).Build()
+		if !errors.Is(err, kyte.ErrValueTypeMismatch) {
+			t.Errorf("Filter.Build should return error %v, got %v", kyte.ErrValueTypeMismatch, err)
+		}
+	})
+
+	t.Run("invalid key pattern tag", func(t *testing.T) {
+		type Invalid struct {
+			Attrs map[string]string `bson:"attrs" kyte:"keys=[a-z"`
+		}
+
+		type NotMap struct {
+			Name string `bson:"name" kyte:"keys=^[a-z]+$"`
+		}
+
+		for _, source := range []any{&Invalid{}, &NotMap{}} {
+			_, err := kyte.Filter(kyte.Source(source)).Build()
+			if !errors.Is(err, kyte.ErrInvalidStructTag) {
+				t.Errorf("Filter.Build should return error %v, got %v", kyte.ErrInvalidStructTag, err)
+			}
+		}
+	})
+}
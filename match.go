@@ -0,0 +1,774 @@
+package kyte
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+var typeAliases = map[string]bsontype.Type{
+	"double":              bsontype.Double,
+	"string":              bsontype.String,
+	"object":              bsontype.EmbeddedDocument,
+	"array":               bsontype.Array,
+	"binData":             bsontype.Binary,
+	"undefined":           bsontype.Undefined,
+	"objectId":            bsontype.ObjectID,
+	"bool":                bsontype.Boolean,
+	"date":                bsontype.DateTime,
+	"null":                bsontype.Null,
+	"regex":               bsontype.Regex,
+	"dbPointer":           bsontype.DBPointer,
+	"javascript":          bsontype.JavaScript,
+	"symbol":              bsontype.Symbol,
+	"javascriptWithScope": bsontype.CodeWithScope,
+	"int":                 bsontype.Int32,
+	"timestamp":           bsontype.Timestamp,
+	"long":                bsontype.Int64,
+	"decimal":             bsontype.Decimal128,
+	"minKey":              bsontype.MinKey,
+	"maxKey":              bsontype.MaxKey,
+}
+
+/*
+Match builds the filter and evaluates it against the given document in memory, without a running mongod.
+The document can be a struct, bson.M, bson.D or bson.Raw. See [Match] for the supported operators.
+
+	matched, err := Filter().
+		Equal("name", "John").
+		GreaterThan("age", 18).
+		Match(User{Name: "John", Age: 20}) // true
+*/
+func (f *FilterBuilder) Match(doc any) (bool, error) {
+	query, err := f.Build()
+	if err != nil {
+		return false, err
+	}
+
+	return Match(query, doc)
+}
+
+/*
+Match evaluates the given query against the given document in memory, following the MongoDB comparison and BSON type ordering
+and array traversal semantics. The document can be a struct, bson.M, bson.D or bson.Raw.
+
+It supports $eq, $ne, $gt, $gte, $lt, $lte, $in, $nin, $and, $or, $nor, $regex, $exists, $type, $mod, $all, $size, $not and $elemMatch,
+other operators like $where and $jsonSchema return an error.
+
+	Match(bson.D{{Key: "tags", Value: "go"}}, bson.M{"tags": bson.A{"go", "mongo"}}) // true
+*/
+func Match(query bson.D, doc any) (bool, error) {
+	rawQuery, err := toRaw(query)
+	if err != nil {
+		return false, err
+	}
+
+	rawDoc, err := toRaw(doc)
+	if err != nil {
+		return false, err
+	}
+
+	return matchQuery(rawQuery, rawDoc)
+}
+
+func matchQuery(query bson.Raw, doc bson.Raw) (bool, error) {
+	elements, err := query.Elements()
+	if err != nil {
+		return false, err
+	}
+
+	for _, e := range elements {
+		var matched bool
+		switch key := e.Key(); key {
+		case and, or, nor:
+			matched, err = matchLogical(key, e.Value(), doc)
+		default:
+			if strings.HasPrefix(key, "$") {
+				return false, errors.Join(ErrUnsupportedMatchOperator, fmt.Errorf("operator: %s", key))
+			}
+			matched, err = matchField(lookupPath(documentValue(doc), strings.Split(key, ".")), e.Value())
+		}
+
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+func matchLogical(operator string, operand bson.RawValue, doc bson.Raw) (bool, error) {
+	items, err := arrayValues(operator, operand)
+	if err != nil {
+		return false, err
+	}
+
+	if len(items) == 0 {
+		return false, errors.Join(ErrInvalidMatchOperand, fmt.Errorf("operator: %s", operator))
+	}
+
+	for _, item := range items {
+		query, ok := item.DocumentOK()
+		if !ok {
+			return false, errors.Join(ErrInvalidMatchOperand, fmt.Errorf("operator: %s", operator))
+		}
+
+		matched, err := matchQuery(query, doc)
+		if err != nil {
+			return false, err
+		}
+
+		switch {
+		case operator == and && !matched:
+			return false, nil
+		case operator == or && matched:
+			return true, nil
+		case operator == nor && matched:
+			return false, nil
+		}
+	}
+
+	return operator != or, nil
+}
+
+/*
+matchField matches the condition against the values that are found at the path of the field.
+*/
+func matchField(values []bson.RawValue, condition bson.RawValue) (bool, error) {
+	doc, ok := condition.DocumentOK()
+	if !ok || !isOperatorRaw(doc) {
+		return matchEqualOrRegex(values, condition)
+	}
+
+	elements, err := doc.Elements()
+	if err != nil {
+		return false, err
+	}
+
+	for _, e := range elements {
+		if e.Key() == regxOptions {
+			continue
+		}
+
+		matched, err := matchOperator(values, e.Key(), e.Value(), doc)
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+func matchOperator(values []bson.RawValue, operator string, operand bson.RawValue, siblings bson.Raw) (bool, error) {
+	switch operator {
+	case eq:
+		return matchEqual(values, operand), nil
+	case ne:
+		return !matchEqual(values, operand), nil
+	case gt, gte, lt, lte:
+		return matchCompare(values, operator, operand), nil
+	case in, nin:
+		items, err := arrayValues(operator, operand)
+		if err != nil {
+			return false, err
+		}
+
+		matched, err := matchIn(values, items)
+		if operator == nin {
+			matched = !matched
+		}
+		return matched, err
+	case regx:
+		options, _ := siblings.Lookup(regxOptions).StringValueOK()
+		re, err := compileRegex(operand, options)
+		if err != nil {
+			return false, err
+		}
+		return anyValue(expand(values), func(v bson.RawValue) bool { return matchRegex(re, v) }), nil
+	case exists:
+		return len(values) > 0 == isTruthy(operand), nil
+	case _type:
+		return matchType(values, operand)
+	case mod:
+		return matchMod(values, operand)
+	case all:
+		return matchAll(values, operand)
+	case size:
+		n, ok := toInt64(operand)
+		if !ok {
+			return false, errors.Join(ErrInvalidMatchOperand, fmt.Errorf("operator: %s", operator))
+		}
+		return anyValue(values, func(v bson.RawValue) bool {
+			items, ok := v.ArrayOK()
+			if !ok {
+				return false
+			}
+			array, _ := items.Values()
+			return int64(len(array)) == n
+		}), nil
+	case elemMatch:
+		return matchElem(values, operand)
+	case not:
+		if operand.Type == bsontype.Regex {
+			re, err := compileRegex(operand, "")
+			if err != nil {
+				return false, err
+			}
+			return !anyValue(expand(values), func(v bson.RawValue) bool { return matchRegex(re, v) }), nil
+		}
+
+		doc, ok := operand.DocumentOK()
+		if !ok || !isOperatorRaw(doc) {
+			return false, errors.Join(ErrInvalidMatchOperand, fmt.Errorf("operator: %s", operator))
+		}
+
+		matched, err := matchField(values, operand)
+		return !matched, err
+	}
+
+	return false, errors.Join(ErrUnsupportedMatchOperator, fmt.Errorf("operator: %s", operator))
+}
+
+func matchEqual(values []bson.RawValue, operand bson.RawValue) bool {
+	if isNull(operand) && (len(values) == 0 || anyValue(values, isNull)) {
+		return true
+	}
+
+	return anyValue(expand(values), func(v bson.RawValue) bool { return compareValues(v, operand) == 0 })
+}
+
+/*
+matchEqualOrRegex matches the regex values as a pattern like {name: /^J/} and $in do, other values are matched with equality.
+*/
+func matchEqualOrRegex(values []bson.RawValue, operand bson.RawValue) (bool, error) {
+	if operand.Type != bsontype.Regex {
+		return matchEqual(values, operand), nil
+	}
+
+	re, err := compileRegex(operand, "")
+	if err != nil {
+		return false, err
+	}
+
+	return anyValue(expand(values), func(v bson.RawValue) bool {
+		return matchRegex(re, v) || compareValues(v, operand) == 0
+	}), nil
+}
+
+func matchCompare(values []bson.RawValue, operator string, operand bson.RawValue) bool {
+	if isNull(operand) && (operator == gte || operator == lte) && len(values) == 0 {
+		return true
+	}
+
+	return anyValue(expand(values), func(v bson.RawValue) bool {
+		if canonicalOrder(v.Type) != canonicalOrder(operand.Type) {
+			return false
+		}
+
+		c := compareValues(v, operand)
+		switch operator {
+		case gt:
+			return c > 0
+		case gte:
+			return c >= 0
+		case lt:
+			return c < 0
+		default:
+			return c <= 0
+		}
+	})
+}
+
+func matchIn(values []bson.RawValue, items []bson.RawValue) (bool, error) {
+	for _, item := range items {
+		if doc, ok := item.DocumentOK(); ok && isOperatorRaw(doc) {
+			return false, errors.Join(ErrInvalidMatchOperand, fmt.Errorf("operator: %s", in))
+		}
+
+		matched, err := matchEqualOrRegex(values, item)
+		if err != nil || matched {
+			return matched, err
+		}
+	}
+
+	return false, nil
+}
+
+func matchType(values []bson.RawValue, operand bson.RawValue) (bool, error) {
+	types := []bson.RawValue{operand}
+	if items, ok := operand.ArrayOK(); ok {
+		types, _ = items.Values()
+	}
+
+	for _, t := range types {
+		var matches func(bson.RawValue) bool
+		if alias, ok := t.StringValueOK(); ok {
+			if alias == "number" {
+				matches = func(v bson.RawValue) bool { return canonicalOrder(v.Type) == canonicalOrder(bsontype.Double) }
+			} else if bsonType, ok := typeAliases[alias]; ok {
+				matches = func(v bson.RawValue) bool { return v.Type == bsonType }
+			}
+		} else if code, ok := toInt64(t); ok {
+			matches = func(v bson.RawValue) bool { return v.Type == bsontype.Type(code) }
+		}
+
+		if matches == nil {
+			return false, errors.Join(ErrInvalidMatchOperand, fmt.Errorf("operator: %s", _type))
+		}
+
+		// array type is matched against the array itself, not its elements
+		if anyValue(values, matches) || anyValue(expand(values), matches) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func matchMod(values []bson.RawValue, operand bson.RawValue) (bool, error) {
+	items, err := arrayValues(mod, operand)
+	if err != nil {
+		return false, err
+	}
+
+	if len(items) != 2 {
+		return false, errors.Join(ErrInvalidMatchOperand, fmt.Errorf("operator: %s", mod))
+	}
+
+	divisor, ok := toInt64(items[0])
+	remainder, ok2 := toInt64(items[1])
+	if !ok || !ok2 || divisor == 0 {
+		return false, errors.Join(ErrInvalidMatchOperand, fmt.Errorf("operator: %s", mod))
+	}
+
+	return anyValue(expand(values), func(v bson.RawValue) bool {
+		n, ok := toInt64(v)
+		return ok && n%divisor == remainder
+	}), nil
+}
+
+func matchAll(values []bson.RawValue, operand bson.RawValue) (bool, error) {
+	items, err := arrayValues(all, operand)
+	if err != nil {
+		return false, err
+	}
+
+	if len(items) == 0 {
+		return false, nil
+	}
+
+	for _, item := range items {
+		var matched bool
+		if doc, ok := item.DocumentOK(); ok && isOperatorRaw(doc) {
+			matched, err = matchField(values, item)
+			if err != nil {
+				return false, err
+			}
+		} else {
+			matched = matchEqual(values, item)
+		}
+
+		if !matched {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+func matchElem(values []bson.RawValue, operand bson.RawValue) (bool, error) {
+	query, ok := operand.DocumentOK()
+	if !ok {
+		return false, errors.Join(ErrInvalidMatchOperand, fmt.Errorf("operator: %s", elemMatch))
+	}
+
+	// {$elemMatch: {$gte: 80, $lt: 85}} matches the elements itself, {$elemMatch: {score: {$gte: 80}}} matches the element documents
+	valueQuery := isOperatorRaw(query)
+	if first, err := query.IndexErr(0); err == nil {
+		switch first.Key() {
+		case and, or, nor:
+			valueQuery = false
+		}
+	}
+
+	for _, v := range values {
+		items, ok := v.ArrayOK()
+		if !ok {
+			continue
+		}
+
+		elements, err := items.Values()
+		if err != nil {
+			return false, err
+		}
+
+		for _, element := range elements {
+			var matched bool
+			if valueQuery {
+				matched, err = matchField([]bson.RawValue{element}, operand)
+			} else if doc, ok := element.DocumentOK(); ok {
+				matched, err = matchQuery(query, doc)
+			}
+
+			if err != nil {
+				return false, err
+			}
+
+			if matched {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
+/*
+lookupPath returns the values that are found at the path, the arrays on the path are traversed like MongoDB does:
+the numeric segments are used as index and the documents in the array are looked up with the rest of the path.
+*/
+func lookupPath(value bson.RawValue, path []string) []bson.RawValue {
+	if len(path) == 0 {
+		return []bson.RawValue{value}
+	}
+
+	if doc, ok := value.DocumentOK(); ok {
+		v, err := doc.LookupErr(path[0])
+		if err != nil {
+			return nil
+		}
+		return lookupPath(v, path[1:])
+	}
+
+	items, ok := value.ArrayOK()
+	if !ok {
+		return nil
+	}
+
+	var values []bson.RawValue
+	if _, err := strconv.Atoi(path[0]); err == nil {
+		if v, err := items.LookupErr(path[0]); err == nil {
+			values = append(values, lookupPath(v, path[1:])...)
+		}
+	}
+
+	elements, _ := items.Values()
+	for _, element := range elements {
+		if element.Type == bsontype.EmbeddedDocument {
+			values = append(values, lookupPath(element, path)...)
+		}
+	}
+
+	return values
+}
+
+/*
+expand returns the values with the elements of the array values, nested arrays are not expanded.
+*/
+func expand(values []bson.RawValue) []bson.RawValue {
+	expanded := make([]bson.RawValue, 0, len(values))
+	for _, v := range values {
+		expanded = append(expanded, v)
+		if items, ok := v.ArrayOK(); ok {
+			elements, _ := items.Values()
+			expanded = append(expanded, elements...)
+		}
+	}
+	return expanded
+}
+
+func anyValue(values []bson.RawValue, fn func(bson.RawValue) bool) bool {
+	for _, v := range values {
+		if fn(v) {
+			return true
+		}
+	}
+	return false
+}
+
+func arrayValues(operator string, operand bson.RawValue) ([]bson.RawValue, error) {
+	items, ok := operand.ArrayOK()
+	if !ok {
+		return nil, errors.Join(ErrInvalidMatchOperand, fmt.Errorf("operator: %s", operator))
+	}
+
+	return items.Values()
+}
+
+func documentValue(doc bson.Raw) bson.RawValue {
+	return bson.RawValue{Type: bsontype.EmbeddedDocument, Value: doc}
+}
+
+func isOperatorRaw(doc bson.Raw) bool {
+	first, err := doc.IndexErr(0)
+	return err == nil && strings.HasPrefix(first.Key(), "$")
+}
+
+func isNull(v bson.RawValue) bool {
+	return v.Type == bsontype.Null || v.Type == bsontype.Undefined
+}
+
+func isTruthy(v bson.RawValue) bool {
+	switch v.Type {
+	case bsontype.Boolean:
+		return v.Boolean()
+	case bsontype.Null, bsontype.Undefined:
+		return false
+	}
+
+	if n, ok := toFloat64(v); ok {
+		return n != 0
+	}
+	return true
+}
+
+func compileRegex(operand bson.RawValue, options string) (*regexp.Regexp, error) {
+	pattern, ok := operand.StringValueOK()
+	if !ok {
+		var regexOptions string
+		pattern, regexOptions, ok = operand.RegexOK()
+		if !ok {
+			return nil, errors.Join(ErrInvalidMatchOperand, fmt.Errorf("operator: %s", regx))
+		}
+
+		if options == "" {
+			options = regexOptions
+		}
+	}
+
+	flags := ""
+	for _, o := range options {
+		switch o {
+		case 'i', 'm', 's':
+			flags += string(o)
+		default:
+			return nil, errors.Join(ErrInvalidMatchOperand, fmt.Errorf("regex option: %c", o))
+		}
+	}
+
+	if flags != "" {
+		pattern = "(?" + flags + ")" + pattern
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, errors.Join(ErrInvalidMatchOperand, err)
+	}
+	return re, nil
+}
+
+func matchRegex(re *regexp.Regexp, v bson.RawValue) bool {
+	switch v.Type {
+	case bsontype.String:
+		return re.MatchString(v.StringValue())
+	case bsontype.Symbol:
+		return re.MatchString(v.Symbol())
+	}
+	return false
+}
+
+/*
+canonicalOrder returns the order of the BSON type that is used by MongoDB to compare values of different types,
+the numbers and the strings are compared within their own group.
+*/
+func canonicalOrder(t bsontype.Type) int {
+	switch t {
+	case bsontype.MinKey:
+		return 1
+	case bsontype.Null, bsontype.Undefined:
+		return 5
+	case bsontype.Double, bsontype.Int32, bsontype.Int64, bsontype.Decimal128:
+		return 10
+	case bsontype.String, bsontype.Symbol:
+		return 15
+	case bsontype.EmbeddedDocument:
+		return 20
+	case bsontype.Array:
+		return 25
+	case bsontype.Binary:
+		return 30
+	case bsontype.ObjectID:
+		return 35
+	case bsontype.Boolean:
+		return 40
+	case bsontype.DateTime:
+		return 45
+	case bsontype.Timestamp:
+		return 47
+	case bsontype.Regex:
+		return 50
+	case bsontype.DBPointer:
+		return 55
+	case bsontype.JavaScript:
+		return 60
+	case bsontype.CodeWithScope:
+		return 65
+	case bsontype.MaxKey:
+		return 127
+	}
+	return 0
+}
+
+/*
+compareValues compares the given values with the MongoDB comparison order, it returns -1, 0 or 1.
+*/
+func compareValues(a, b bson.RawValue) int {
+	if c := compareInt(int64(canonicalOrder(a.Type)), int64(canonicalOrder(b.Type))); c != 0 {
+		return c
+	}
+
+	switch canonicalOrder(a.Type) {
+	case canonicalOrder(bsontype.Double):
+		return compareNumbers(a, b)
+	case canonicalOrder(bsontype.String):
+		return strings.Compare(toString(a), toString(b))
+	case canonicalOrder(bsontype.EmbeddedDocument), canonicalOrder(bsontype.Array):
+		return compareDocuments(bson.Raw(a.Value), bson.Raw(b.Value))
+	case canonicalOrder(bsontype.Binary):
+		subtypeA, dataA := a.Binary()
+		subtypeB, dataB := b.Binary()
+		if c := compareInt(int64(len(dataA)), int64(len(dataB))); c != 0 {
+			return c
+		}
+		if c := compareInt(int64(subtypeA), int64(subtypeB)); c != 0 {
+			return c
+		}
+		return bytes.Compare(dataA, dataB)
+	case canonicalOrder(bsontype.ObjectID):
+		idA, idB := a.ObjectID(), b.ObjectID()
+		return bytes.Compare(idA[:], idB[:])
+	case canonicalOrder(bsontype.Boolean):
+		return compareInt(boolToInt(a.Boolean()), boolToInt(b.Boolean()))
+	case canonicalOrder(bsontype.DateTime):
+		return compareInt(a.DateTime(), b.DateTime())
+	case canonicalOrder(bsontype.Timestamp):
+		tA, iA := a.Timestamp()
+		tB, iB := b.Timestamp()
+		if c := compareInt(int64(tA), int64(tB)); c != 0 {
+			return c
+		}
+		return compareInt(int64(iA), int64(iB))
+	case canonicalOrder(bsontype.Regex):
+		patternA, optionsA := a.Regex()
+		patternB, optionsB := b.Regex()
+		if c := strings.Compare(patternA, patternB); c != 0 {
+			return c
+		}
+		return strings.Compare(optionsA, optionsB)
+	case canonicalOrder(bsontype.MinKey), canonicalOrder(bsontype.Null), canonicalOrder(bsontype.MaxKey):
+		return 0
+	}
+
+	return bytes.Compare(a.Value, b.Value)
+}
+
+/*
+compareDocuments compares the documents element by element, first by the type of the values, then by the keys and then by the values.
+Arrays are compared the same way since they are documents with index keys.
+*/
+func compareDocuments(a, b bson.Raw) int {
+	elementsA, _ := a.Elements()
+	elementsB, _ := b.Elements()
+
+	for i := 0; i < len(elementsA) && i < len(elementsB); i++ {
+		valueA, valueB := elementsA[i].Value(), elementsB[i].Value()
+		if c := compareInt(int64(canonicalOrder(valueA.Type)), int64(canonicalOrder(valueB.Type))); c != 0 {
+			return c
+		}
+		if c := strings.Compare(elementsA[i].Key(), elementsB[i].Key()); c != 0 {
+			return c
+		}
+		if c := compareValues(valueA, valueB); c != 0 {
+			return c
+		}
+	}
+
+	return compareInt(int64(len(elementsA)), int64(len(elementsB)))
+}
+
+func compareNumbers(a, b bson.RawValue) int {
+	intA, okA := integerValue(a)
+	intB, okB := integerValue(b)
+	if okA && okB {
+		return compareInt(intA, intB)
+	}
+
+	floatA, _ := toFloat64(a)
+	floatB, _ := toFloat64(b)
+	switch {
+	case math.IsNaN(floatA) && math.IsNaN(floatB):
+		return 0
+	case math.IsNaN(floatA), floatA < floatB:
+		return -1
+	case math.IsNaN(floatB), floatA > floatB:
+		return 1
+	}
+	return 0
+}
+
+func compareInt(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func boolToInt(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func toString(v bson.RawValue) string {
+	if v.Type == bsontype.Symbol {
+		return v.Symbol()
+	}
+	return v.StringValue()
+}
+
+func integerValue(v bson.RawValue) (int64, bool) {
+	switch v.Type {
+	case bsontype.Int32:
+		return int64(v.Int32()), true
+	case bsontype.Int64:
+		return v.Int64(), true
+	}
+	return 0, false
+}
+
+func toInt64(v bson.RawValue) (int64, bool) {
+	if n, ok := integerValue(v); ok {
+		return n, true
+	}
+
+	if n, ok := toFloat64(v); ok && !math.IsNaN(n) && !math.IsInf(n, 0) {
+		return int64(n), true
+	}
+	return 0, false
+}
+
+func toFloat64(v bson.RawValue) (float64, bool) {
+	switch v.Type {
+	case bsontype.Double:
+		return v.Double(), true
+	case bsontype.Int32:
+		return float64(v.Int32()), true
+	case bsontype.Int64:
+		return float64(v.Int64()), true
+	case bsontype.Decimal128:
+		n, err := strconv.ParseFloat(v.Decimal128().String(), 64)
+		return n, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,193 @@
+package kyte_test
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/aaydin-tr/kyte"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestFilter_Match(t *testing.T) {
+	t.Parallel()
+
+	type Address struct {
+		City string `bson:"city"`
+	}
+
+	type User struct {
+		Name      string    `bson:"name"`
+		Age       int       `bson:"age"`
+		Tags      []string  `bson:"tags"`
+		Addresses []Address `bson:"addresses"`
+	}
+
+	user := User{
+		Name:      "John",
+		Age:       30,
+		Tags:      []string{"go", "mongo"},
+		Addresses: []Address{{City: "Istanbul"}, {City: "Berlin"}},
+	}
+
+	t.Run("with source", func(t *testing.T) {
+		var temp User
+		matched, err := kyte.Filter(kyte.Source(&temp)).
+			Equal(&temp.Name, "John").
+			GreaterThanOrEqual(&temp.Age, 18).
+			In(&temp.Tags, []string{"go"}).
+			Match(user)
+		if err != nil {
+			t.Errorf("Filter.Match should not return error: %v", err)
+		}
+
+		if !matched {
+			t.Errorf("Filter.Match should match the document")
+		}
+	})
+
+	t.Run("not matched", func(t *testing.T) {
+		matched, err := kyte.Filter().
+			Equal("name", "John").
+			LessThan("age", 18).
+			Match(&user)
+		if err != nil {
+			t.Errorf("Filter.Match should not return error: %v", err)
+		}
+
+		if matched {
+			t.Errorf("Filter.Match should not match the document")
+		}
+	})
+
+	t.Run("build error", func(t *testing.T) {
+		var temp User
+		_, err := kyte.Filter(kyte.Source(&temp)).Equal("surname", "Doe").Match(user)
+		if !errors.Is(err, kyte.ErrNotValidFieldForQuery) {
+			t.Errorf("Filter.Match should return error %v, got %v", kyte.ErrNotValidFieldForQuery, err)
+		}
+	})
+
+	t.Run("operators", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			filter  interface{ Match(any) (bool, error) }
+			matched bool
+		}{
+			{"not equal", kyte.Filter().NotEqual("name", "Doe"), true},
+			{"greater than", kyte.Filter().GreaterThan("age", 30), false},
+			{"less than or equal", kyte.Filter().LessThanOrEqual("age", 30), true},
+			{"not in", kyte.Filter().NotIn("tags", []string{"js"}), true},
+			{"and", kyte.Filter().And(kyte.Filter().Equal("name", "John").Equal("age", 30)), true},
+			{"or", kyte.Filter().Or(kyte.Filter().Equal("name", "Doe").Equal("age", 30)), true},
+			{"nor", kyte.Filter().NOR(kyte.Filter().Equal("name", "Doe").Equal("age", 31)), true},
+			{"regex", kyte.Filter().Regex("name", regexp.MustCompile("^j"), "i"), true},
+			{"exists", kyte.Filter().Exists("email", false), true},
+			{"type", kyte.Filter().Type("age", bson.TypeInt32, bson.TypeInt64), true},
+			{"type array", kyte.Filter().Type("tags", bson.TypeArray), true},
+			{"mod", kyte.Filter().Mod("age", 7, 2), true},
+			{"all", kyte.Filter().All("tags", []string{"mongo", "go"}), true},
+			{"size", kyte.Filter().Size("tags", 3), false},
+			{"not", kyte.Filter().Not().GreaterThan("age", 40), true},
+			{"negate", kyte.Filter().Equal("name", "John").GreaterThan("age", 18).Negate(), false},
+			{"nested array path", kyte.Filter().Equal("addresses.city", "Berlin"), true},
+			{"array index path", kyte.Filter().Equal("addresses.0.city", "Berlin"), false},
+			{"elem match", kyte.Filter().ElemMatch("addresses", kyte.Filter().Equal("city", "Istanbul")), true},
+			{"elem match scalar", kyte.Filter().ElemMatch("tags", kyte.Filter().Regex(kyte.Elem, regexp.MustCompile("^mon"))), true},
+		}
+
+		for _, tt := range tests {
+			matched, err := tt.filter.Match(user)
+			if err != nil {
+				t.Errorf("%s: Filter.Match should not return error: %v", tt.name, err)
+			}
+
+			if matched != tt.matched {
+				t.Errorf("%s: Filter.Match should return %v, got %v", tt.name, tt.matched, matched)
+			}
+		}
+	})
+}
+
+func TestMatch(t *testing.T) {
+	t.Parallel()
+
+	t.Run("document types", func(t *testing.T) {
+		query := bson.D{{Key: "name", Value: "John"}, {Key: "age", Value: bson.M{"$gt": 18.5}}}
+		raw, err := bson.Marshal(bson.M{"name": "John", "age": int64(20)})
+		if err != nil {
+			t.Fatalf("bson.Marshal should not return error: %v", err)
+		}
+
+		docs := []any{
+			bson.M{"name": "John", "age": 20},
+			bson.D{{Key: "name", Value: "John"}, {Key: "age", Value: 20.0}},
+			bson.Raw(raw),
+		}
+
+		for _, doc := range docs {
+			matched, err := kyte.Match(query, doc)
+			if err != nil {
+				t.Errorf("Match should not return error: %v", err)
+			}
+
+			if !matched {
+				t.Errorf("Match should match the document %v", doc)
+			}
+		}
+	})
+
+	t.Run("comparison semantics", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			query   bson.D
+			doc     any
+			matched bool
+		}{
+			{"numbers of different types", bson.D{{Key: "n", Value: 1}}, bson.M{"n": 1.0}, true},
+			{"different type brackets", bson.D{{Key: "n", Value: bson.M{"$gt": 1}}}, bson.M{"n": "2"}, false},
+			{"null matches missing", bson.D{{Key: "n", Value: nil}}, bson.M{}, true},
+			{"not equal to missing", bson.D{{Key: "n", Value: bson.M{"$ne": 1}}}, bson.M{}, true},
+			{"array element", bson.D{{Key: "n", Value: 2}}, bson.M{"n": bson.A{1, 2}}, true},
+			{"whole array", bson.D{{Key: "n", Value: bson.A{1, 2}}}, bson.M{"n": bson.A{1, 2}}, true},
+			{"nested array is not traversed", bson.D{{Key: "n", Value: 1}}, bson.M{"n": bson.A{bson.A{1}}}, false},
+			{"range on array elements", bson.D{{Key: "n", Value: bson.M{"$gt": 1, "$lt": 3}}}, bson.M{"n": bson.A{0, 5}}, true},
+			{"embedded document order", bson.D{{Key: "d", Value: bson.D{{Key: "a", Value: 1}, {Key: "b", Value: 2}}}}, bson.M{"d": bson.D{{Key: "b", Value: 2}, {Key: "a", Value: 1}}}, false},
+			{"regex literal", bson.D{{Key: "s", Value: primitive.Regex{Pattern: "^a", Options: "i"}}}, bson.M{"s": "Abc"}, true},
+			{"regex in", bson.D{{Key: "s", Value: bson.M{"$in": bson.A{primitive.Regex{Pattern: "c$"}}}}}, bson.M{"s": "abc"}, true},
+			{"type alias", bson.D{{Key: "n", Value: bson.M{"$type": "number"}}}, bson.M{"n": 1.5}, true},
+			{"object id", bson.D{{Key: "_id", Value: bson.M{"$lt": primitive.ObjectID{2}}}}, bson.M{"_id": primitive.ObjectID{1}}, true},
+		}
+
+		for _, tt := range tests {
+			matched, err := kyte.Match(tt.query, tt.doc)
+			if err != nil {
+				t.Errorf("%s: Match should not return error: %v", tt.name, err)
+			}
+
+			if matched != tt.matched {
+				t.Errorf("%s: Match should return %v, got %v", tt.name, tt.matched, matched)
+			}
+		}
+	})
+
+	t.Run("unsupported operator", func(t *testing.T) {
+		_, err := kyte.Match(bson.D{{Key: "$where", Value: "this.age > 18"}}, bson.M{"age": 20})
+		if !errors.Is(err, kyte.ErrUnsupportedMatchOperator) {
+			t.Errorf("Match should return error %v, got %v", kyte.ErrUnsupportedMatchOperator, err)
+		}
+
+		_, err = kyte.Match(bson.D{{Key: "age", Value: bson.M{"$near": bson.A{1, 2}}}}, bson.M{"age": 20})
+		if !errors.Is(err, kyte.ErrUnsupportedMatchOperator) {
+			t.Errorf("Match should return error %v, got %v", kyte.ErrUnsupportedMatchOperator, err)
+		}
+	})
+
+	t.Run("invalid operand", func(t *testing.T) {
+		_, err := kyte.Match(bson.D{{Key: "age", Value: bson.M{"$in": 1}}}, bson.M{"age": 20})
+		if !errors.Is(err, kyte.ErrInvalidMatchOperand) {
+			t.Errorf("Match should return error %v, got %v", kyte.ErrInvalidMatchOperand, err)
+		}
+	})
+}
@@ -0,0 +1,292 @@
+package kyte
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+/*
+Negate rewrites the filter into its logical complement by using De Morgan's laws instead of wrapping the whole query with [$nor],
+so the result can still use indexes. Global filters are not negated.
+
+	Filter().
+		Equal("name", "John").
+		GreaterThan("age", 18).
+		Negate() // {"$or": [{"name": {"$ne": "John"}}, {"age": {"$not": {"$gt": 18}}}]}
+
+The following rewrites are applied, operators without an inverse fall back to [$nor].
+
+	$eq <-> $ne, $in <-> $nin, $exists: true <-> $exists: false, $not: x -> x
+	$gt, $gte, $lt, $lte, $regex -> $not
+	$and -> $or of negations, $or -> $and of negations, $nor -> $or
+
+The range operators are wrapped with [$not] instead of being flipped, because $lte is not the complement of $gt for the array fields
+and the documents where the field is missing or has a different type, {"a": [1, 5]} matches both {"$gt": 3} and {"$lte": 3}.
+
+[$nor]: https://www.mongodb.com/docs/manual/reference/operator/query/nor/#mongodb-query-op.-nor
+[$not]: https://www.mongodb.com/docs/manual/reference/operator/query/not/#mongodb-query-op.-not
+*/
+func (f *FilterBuilder) Negate() *FilterBuilder {
+	query, err := f.Build()
+	if err != nil {
+		return f
+	}
+
+	negated, err := negateQuery(query[f.globals:])
+	if err != nil {
+		f.kyte.setError(err)
+		return f
+	}
+
+	f.query = append(query[:f.globals:f.globals], negated...)
+	f.operations = nil
+	f.isBuild = false
+	return f
+}
+
+func negateQuery(query bson.D) (bson.D, error) {
+	if len(query) == 0 {
+		return nil, ErrNegateEmptyFilter
+	}
+
+	negated := make([]bson.E, 0, len(query))
+	for _, q := range query {
+		n, err := negateElement(q)
+		if err != nil {
+			return nil, err
+		}
+		negated = append(negated, n)
+	}
+
+	return bson.D{anyOf(negated)}, nil
+}
+
+func negateElement(e bson.E) (bson.E, error) {
+	switch e.Key {
+	case and:
+		docs, ok := toArray(e.Value)
+		if !ok {
+			return norOf(e), nil
+		}
+
+		negated := make([]bson.E, 0, len(docs))
+		for _, doc := range docs {
+			n, err := negateDocument(e.Key, doc)
+			if err != nil {
+				return bson.E{}, err
+			}
+			negated = append(negated, n)
+		}
+
+		return bson.E{Key: or, Value: toItems(negated)}, nil
+	case or:
+		docs, ok := toArray(e.Value)
+		if !ok {
+			return norOf(e), nil
+		}
+
+		negated := make([]bson.E, 0, len(docs))
+		for _, doc := range docs {
+			n, err := negateDocument(e.Key, doc)
+			if err != nil {
+				return bson.E{}, err
+			}
+			negated = append(negated, n)
+		}
+
+		return bson.E{Key: and, Value: toItems(negated)}, nil
+	case nor:
+		docs, ok := toArray(e.Value)
+		if !ok {
+			return norOf(e), nil
+		}
+
+		for _, doc := range docs {
+			if _, ok := toDocument(doc); !ok {
+				return bson.E{}, errors.Join(ErrInvalidMatchOperand, fmt.Errorf("operator: %s", nor))
+			}
+		}
+
+		return bson.E{Key: or, Value: e.Value}, nil
+	}
+
+	if strings.HasPrefix(e.Key, "$") {
+		return norOf(e), nil
+	}
+
+	return negateField(e.Key, e.Value), nil
+}
+
+func negateDocument(operator string, doc any) (bson.E, error) {
+	d, ok := toDocument(doc)
+	if !ok {
+		return bson.E{}, errors.Join(ErrInvalidMatchOperand, fmt.Errorf("operator: %s", operator))
+	}
+
+	negated, err := negateQuery(d)
+	if err != nil {
+		return bson.E{}, err
+	}
+
+	return negated[0], nil
+}
+
+func negateField(field string, value any) bson.E {
+	operators, ok := toDocument(value)
+	if !ok || !isOperatorDocument(operators) {
+		if _, ok := value.(primitive.Regex); ok {
+			return bson.E{Key: field, Value: bson.M{not: value}}
+		}
+		return bson.E{Key: field, Value: bson.M{ne: value}}
+	}
+
+	negated := []bson.E{}
+	for _, o := range operators {
+		switch o.Key {
+		case eq:
+			negated = append(negated, bson.E{Key: field, Value: bson.M{ne: o.Value}})
+		case ne:
+			negated = append(negated, bson.E{Key: field, Value: bson.M{eq: o.Value}})
+		case gt, gte, lt, lte:
+			negated = append(negated, bson.E{Key: field, Value: bson.M{not: bson.M{o.Key: o.Value}}})
+		case in:
+			negated = append(negated, bson.E{Key: field, Value: bson.M{nin: o.Value}})
+		case nin:
+			negated = append(negated, bson.E{Key: field, Value: bson.M{in: o.Value}})
+		case not:
+			negated = append(negated, bson.E{Key: field, Value: o.Value})
+		case exists:
+			if b, ok := o.Value.(bool); ok {
+				negated = append(negated, bson.E{Key: field, Value: bson.M{exists: !b}})
+				continue
+			}
+			negated = append(negated, norOf(bson.E{Key: field, Value: bson.M{o.Key: o.Value}}))
+		case regxOptions:
+			// negated together with $regex
+		case regx:
+			if regex, ok := regexOf(o.Value, operators); ok {
+				negated = append(negated, bson.E{Key: field, Value: bson.M{not: regex}})
+				continue
+			}
+			negated = append(negated, norOf(bson.E{Key: field, Value: bson.M{o.Key: o.Value}}))
+		default:
+			negated = append(negated, norOf(bson.E{Key: field, Value: bson.M{o.Key: o.Value}}))
+		}
+	}
+
+	return anyOf(negated)
+}
+
+/*
+regexOf converts the $regex operand and the $options of the operator document to a regular expression value that can be used with $not.
+*/
+func regexOf(value any, operators bson.D) (primitive.Regex, bool) {
+	options := ""
+	for _, o := range operators {
+		if o.Key == regxOptions {
+			s, ok := o.Value.(string)
+			if !ok {
+				return primitive.Regex{}, false
+			}
+			options = s
+		}
+	}
+
+	switch v := value.(type) {
+	case string:
+		return primitive.Regex{Pattern: v, Options: options}, true
+	case primitive.Regex:
+		if options == "" {
+			return v, true
+		}
+		return primitive.Regex{Pattern: v.Pattern, Options: options}, true
+	}
+
+	return primitive.Regex{}, false
+}
+
+func anyOf(query []bson.E) bson.E {
+	if len(query) == 1 {
+		return query[0]
+	}
+
+	return bson.E{Key: or, Value: toItems(query)}
+}
+
+func norOf(e bson.E) bson.E {
+	return bson.E{Key: nor, Value: bson.A{bson.M{e.Key: e.Value}}}
+}
+
+func toItems(query []bson.E) bson.A {
+	items := bson.A{}
+	for _, q := range query {
+		items = append(items, bson.M{q.Key: q.Value})
+	}
+	return items
+}
+
+func isOperatorDocument(doc bson.D) bool {
+	if len(doc) == 0 {
+		return false
+	}
+
+	for _, e := range doc {
+		if !strings.HasPrefix(e.Key, "$") {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+toDocument converts the given value to bson.D, keys of the maps are sorted to keep the output stable.
+*/
+func toDocument(value any) (bson.D, bool) {
+	switch v := value.(type) {
+	case bson.D:
+		return v, true
+	case bson.M:
+		return mapToDocument(v), true
+	case map[string]any:
+		return mapToDocument(v), true
+	}
+
+	return nil, false
+}
+
+func mapToDocument(m map[string]any) bson.D {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	doc := make(bson.D, 0, len(m))
+	for _, key := range keys {
+		doc = append(doc, bson.E{Key: key, Value: m[key]})
+	}
+	return doc
+}
+
+func toArray(value any) ([]any, bool) {
+	if value == nil {
+		return nil, false
+	}
+
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, false
+	}
+
+	items := make([]any, 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		items = append(items, v.Index(i).Interface())
+	}
+	return items, true
+}
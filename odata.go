@@ -0,0 +1,463 @@
+package kyte
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// odataOperators are the comparison operators of OData, in takes a list of values.
+var odataOperators = map[string]string{
+	"eq": eq,
+	"ne": ne,
+	"gt": gt,
+	"ge": gte,
+	"lt": lt,
+	"le": lte,
+	"in": in,
+}
+
+// odataUnsupported are the operators and the functions of OData that cannot be translated to a filter.
+var odataUnsupported = []string{
+	"has", "add", "sub", "mul", "div", "divby", "mod",
+	"any", "all", "cast", "isof", "length", "indexof", "substring", "matchespattern", "toupper", "trim", "concat",
+	"year", "month", "day", "hour", "minute", "second", "fractionalseconds", "totalseconds", "date", "time", "now",
+	"round", "floor", "ceiling", "geo.distance", "geo.intersects", "geo.length",
+}
+
+/*
+FromOData creates a filter from an [OData] v4 $filter expression. The properties are the names of the source struct fields and
+the nested properties are separated by a slash, they are mapped to the bson paths of the fields. The properties that are not
+a field name are used as bson paths.
+
+	kyte.FromOData("Age gt 30 and startswith(Name,'J')", kyte.Source(&user))
+	// {"age": {"$gt": 30}, "name": {"$regex": "^J"}}
+
+The comparison operators are eq, ne, gt, ge, lt, le and in, the logical operators are and, or and not. The string functions
+startswith, endswith and contains are translated to $regex and tolower makes them case insensitive, tolower(Name) eq 'john'
+matches the whole value. The literals are strings in single quotes, numbers, true, false, null and the dates such as 2024-01-02.
+
+The operators and the fields can be limited with [AllowOperators] and [AllowFields], the string functions need the regex operator.
+A syntax error is returned as [*SyntaxError] and a valid construct that cannot be translated such as the arithmetic operators,
+the lambda operators and the other functions is returned as [*UnsupportedError]. The parentheses and not cannot be nested deeper than 64 levels.
+
+[OData]: https://docs.oasis-open.org/odata/odata/v4.01/odata-v4.01-part2-url-conventions.html#sec_SystemQueryOptionfilter
+*/
+func FromOData(filter string, opts ...OptionFunc) (*FilterBuilder, error) {
+	b, f, err := newExpressionBuilder(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSpace(filter) == "" {
+		return f, nil
+	}
+
+	p := &odataParser{kyte: f.kyte, input: filter}
+	if err := p.tokenize(); err != nil {
+		return nil, err
+	}
+
+	expr, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := b.apply(f, expr); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+/*
+odataParser is a recursive descent parser of the OData $filter expressions:
+
+	or         = and { "or" and }
+	and        = unary { "and" unary }
+	unary      = "not" unary | primary
+	primary    = "(" or ")" | function [ ( "eq" | "ne" ) boolean ] | operand [ comparator value | "in" "(" value { "," value } ")" ]
+	function   = ( "startswith" | "endswith" | "contains" ) "(" operand "," string ")"
+	operand    = property | "tolower" "(" property ")"
+*/
+type odataParser struct {
+	tokenStream
+	kyte  *kyte
+	input string
+}
+
+func (p *odataParser) tokenize() error {
+	i := 0
+	for i < len(p.input) {
+		c := p.input[i]
+		switch {
+		case isSpace(c):
+			i++
+		case c == '(':
+			p.add(tokenOpen, "(", i)
+			i++
+		case c == ')':
+			p.add(tokenClose, ")", i)
+			i++
+		case c == ',':
+			p.add(tokenComma, ",", i)
+			i++
+		case c == '\'':
+			text, end, ok := scanQuoted(p.input, i)
+			if !ok {
+				return &SyntaxError{Offset: i, Msg: "unterminated string literal"}
+			}
+			p.add(tokenString, text, i)
+			i = end
+		case isDigit(c) || (c == '-' && i+1 < len(p.input) && isDigit(p.input[i+1])):
+			start := i
+			for i++; i < len(p.input) && isODataLiteralChar(p.input[i]); i++ {
+			}
+			p.add(tokenNumber, p.input[start:i], start)
+		case isASCIILetter(c) || c == '_' || c == '$' || c == '@':
+			start := i
+			for i++; i < len(p.input) && (isASCIILetter(p.input[i]) || isDigit(p.input[i]) || strings.IndexByte("_/.", p.input[i]) >= 0); i++ {
+			}
+			p.add(tokenIdent, p.input[start:i], start)
+		default:
+			// the unknown characters are reported by the parser, so the unsupported constructs before them are reported first
+			p.add(tokenOther, string(c), i)
+			i++
+		}
+	}
+
+	p.add(tokenEOF, "", len(p.input))
+	return nil
+}
+
+func (p *odataParser) parse() (expression, error) {
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, unexpectedError(t)
+	}
+	return expr, nil
+}
+
+func (p *odataParser) parseOr() (expression, error) {
+	return parseLogical(or, p.peek().pos, p.parseAnd, func() bool { return p.keyword("or") })
+}
+
+func (p *odataParser) parseAnd() (expression, error) {
+	return parseLogical(and, p.peek().pos, p.parseUnary, func() bool { return p.keyword("and") })
+}
+
+func (p *odataParser) parseUnary() (expression, error) {
+	if t := p.peek(); p.keyword("not") {
+		if err := p.enter(t); err != nil {
+			return nil, err
+		}
+
+		expr, err := p.parseUnary()
+		p.leave()
+		if err != nil {
+			return nil, err
+		}
+		return negateExpr(expr), nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *odataParser) parsePrimary() (expression, error) {
+	t := p.peek()
+	switch t.kind {
+	case tokenOpen:
+		if err := p.enter(p.next()); err != nil {
+			return nil, err
+		}
+
+		expr, err := p.parseOr()
+		p.leave()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := p.expect(tokenClose, "')'"); err != nil {
+			return nil, err
+		}
+		return expr, nil
+	case tokenIdent:
+	case tokenString, tokenNumber:
+		return nil, &UnsupportedError{Offset: t.pos, Construct: "literal on the left side of the comparison"}
+	default:
+		return nil, unexpectedError(t)
+	}
+
+	switch name := strings.ToLower(t.text); name {
+	case "startswith", "endswith", "contains":
+		if p.peekAt(1).kind == tokenOpen {
+			return p.parseFunction(name)
+		}
+	case "true", "false", "null":
+		return nil, &UnsupportedError{Offset: t.pos, Construct: "literal on the left side of the comparison"}
+	}
+
+	field, lower, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	op := p.peek()
+	if op.kind != tokenIdent || contains([]string{"and", "or"}, op.text) {
+		if lower {
+			return nil, unexpectedError(op)
+		}
+		// a boolean property is a condition itself
+		return &comparisonExpr{pos: t.pos, field: field, operator: eq, values: []literal{{raw: "true", value: true}}}, nil
+	}
+
+	if contains(odataUnsupported, op.text) {
+		return nil, &UnsupportedError{Offset: op.pos, Construct: fmt.Sprintf("operator %s", op.text)}
+	}
+
+	operator, ok := odataOperators[op.text]
+	if !ok {
+		return nil, &SyntaxError{Offset: op.pos, Msg: fmt.Sprintf("unknown operator %q", op.text)}
+	}
+	p.next()
+
+	if lower {
+		return p.parseLowerComparison(t.pos, field, operator, op)
+	}
+
+	if operator == in {
+		values, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+		return &comparisonExpr{pos: t.pos, field: field, operator: in, values: values}, nil
+	}
+
+	value, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	return &comparisonExpr{pos: t.pos, field: field, operator: operator, values: []literal{value}}, nil
+}
+
+/*
+parseFunction parses a string function as a $regex comparison, the function can be compared with a boolean.
+*/
+func (p *odataParser) parseFunction(name string) (expression, error) {
+	start := p.next().pos
+	p.next()
+
+	field, lower, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.expect(tokenComma, "','"); err != nil {
+		return nil, err
+	}
+
+	arg := p.next()
+	if arg.kind != tokenString {
+		if arg.kind == tokenIdent {
+			return nil, &UnsupportedError{Offset: arg.pos, Construct: fmt.Sprintf("%s with a non string argument", name)}
+		}
+		return nil, expectedError(arg, "a string literal")
+	}
+
+	if err := p.expect(tokenClose, "')'"); err != nil {
+		return nil, err
+	}
+
+	pattern := regexp.QuoteMeta(arg.text)
+	switch name {
+	case "startswith":
+		pattern = "^" + pattern
+	case "endswith":
+		pattern = pattern + "$"
+	}
+
+	var expr expression = &comparisonExpr{pos: start, field: field, operator: regx, values: []literal{{raw: pattern, value: pattern}}, options: caseOptions(lower)}
+	if op := p.peek(); op.kind == tokenIdent && (op.text == "eq" || op.text == "ne") {
+		p.next()
+		value := p.next()
+		if value.kind != tokenIdent || (value.text != "true" && value.text != "false") {
+			return nil, expectedError(value, "true or false")
+		}
+
+		if (op.text == "eq") != (value.text == "true") {
+			expr = negateExpr(expr)
+		}
+	}
+
+	return expr, nil
+}
+
+/*
+parseLowerComparison parses the comparison of tolower(property) as a case insensitive $regex that matches the whole value.
+*/
+func (p *odataParser) parseLowerComparison(start int, field string, operator string, op token) (expression, error) {
+	if operator != eq && operator != ne {
+		return nil, &UnsupportedError{Offset: op.pos, Construct: fmt.Sprintf("tolower with the operator %s", op.text)}
+	}
+
+	value := p.next()
+	if value.kind != tokenString {
+		return nil, expectedError(value, "a string literal")
+	}
+
+	pattern := "^" + regexp.QuoteMeta(value.text) + "$"
+	expr := &comparisonExpr{pos: start, field: field, operator: regx, values: []literal{{raw: pattern, value: pattern}}, options: "i", negate: operator == ne}
+	return expr, nil
+}
+
+/*
+parseOperand parses a property or tolower(property) and returns the bson path of the property.
+*/
+func (p *odataParser) parseOperand() (string, bool, error) {
+	t := p.next()
+	if t.kind != tokenIdent {
+		return "", false, expectedError(t, "a property")
+	}
+
+	for _, segment := range strings.Split(t.text, "/") {
+		if segment == "" {
+			return "", false, &SyntaxError{Offset: t.pos, Msg: fmt.Sprintf("invalid property %q", t.text)}
+		}
+		if contains([]string{"any", "all"}, segment) {
+			return "", false, &UnsupportedError{Offset: t.pos, Construct: fmt.Sprintf("lambda operator %s", segment)}
+		}
+	}
+
+	name := strings.ToLower(t.text)
+	if p.peek().kind == tokenOpen {
+		if name != "tolower" {
+			return "", false, &UnsupportedError{Offset: t.pos, Construct: fmt.Sprintf("function %s", t.text)}
+		}
+
+		p.next()
+		field, lower, err := p.parseOperand()
+		if err != nil {
+			return "", false, err
+		}
+		if lower {
+			return "", false, &UnsupportedError{Offset: t.pos, Construct: "nested tolower"}
+		}
+
+		if err := p.expect(tokenClose, "')'"); err != nil {
+			return "", false, err
+		}
+		return field, true, nil
+	}
+
+	if strings.HasPrefix(t.text, "$") || strings.HasPrefix(t.text, "@") {
+		return "", false, &UnsupportedError{Offset: t.pos, Construct: fmt.Sprintf("identifier %s", t.text)}
+	}
+
+	if strings.Contains(t.text, ".") {
+		return "", false, &UnsupportedError{Offset: t.pos, Construct: fmt.Sprintf("qualified name %s", t.text)}
+	}
+
+	return p.kyte.propertyPath(t.text), false, nil
+}
+
+func (p *odataParser) parseList() ([]literal, error) {
+	if err := p.expect(tokenOpen, "'('"); err != nil {
+		return nil, err
+	}
+
+	var values []literal
+	for {
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+
+		t := p.next()
+		if t.kind == tokenClose {
+			return values, nil
+		}
+		if t.kind != tokenComma {
+			return nil, expectedError(t, "',' or ')'")
+		}
+	}
+}
+
+func (p *odataParser) parseValue() (literal, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenString:
+		return literal{raw: t.text, value: t.text}, nil
+	case tokenNumber:
+		if date, err := parseTime(t.text); err == nil {
+			return literal{raw: t.text, value: date}, nil
+		}
+		return parseNumber(t, t.text)
+	case tokenIdent:
+		switch t.text {
+		case "true", "false":
+			return literal{raw: t.text, value: t.text == "true"}, nil
+		case "null":
+			return literal{raw: t.text}, nil
+		}
+
+		if p.peek().kind == tokenOpen {
+			return literal{}, &UnsupportedError{Offset: t.pos, Construct: fmt.Sprintf("function %s", t.text)}
+		}
+		return literal{}, &UnsupportedError{Offset: t.pos, Construct: "comparison of two properties"}
+	}
+
+	return literal{}, expectedError(t, "a value")
+}
+
+/*
+propertyPath maps the OData property to the bson path with the cached schema of the source, a segment is matched with the Go field names,
+then with the bson names and then with the keys of a map field. The segments after an unknown segment are used as they are.
+*/
+func (k *kyte) propertyPath(property string) string {
+	segments := strings.Split(property, "/")
+	if k.schema == nil {
+		return strings.Join(segments, ".")
+	}
+
+	path := make([]string, 0, len(segments))
+	pattern, goName := "", ""
+	for i, segment := range segments {
+		if bsonPath, ok := k.schema.goPaths[joinPath(goName, segment)]; ok {
+			path = append(path, bsonPath[strings.LastIndex(bsonPath, ".")+1:])
+			pattern, goName = bsonPath, joinPath(goName, segment)
+			continue
+		}
+
+		next := joinPath(pattern, segment)
+		if _, ok := k.schema.types[next]; !ok {
+			next = joinPath(pattern, wildcard)
+			if _, ok := k.schema.types[next]; !ok {
+				return strings.Join(append(path, segments[i:]...), ".")
+			}
+		}
+
+		path = append(path, segment)
+		pattern, goName = next, k.schema.goNames[next]
+	}
+
+	return strings.Join(path, ".")
+}
+
+func caseOptions(insensitive bool) string {
+	if insensitive {
+		return "i"
+	}
+	return ""
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isODataLiteralChar(c byte) bool {
+	return isDigit(c) || isASCIILetter(c) || strings.IndexByte(".:+-", c) >= 0
+}
@@ -0,0 +1,211 @@
+package kyte_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aaydin-tr/kyte"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestFromOData(t *testing.T) {
+	t.Parallel()
+
+	var user TestUser
+	tests := []struct {
+		name   string
+		filter string
+		target bson.D
+	}{
+		{
+			name:   "comparison and string function",
+			filter: "Age gt 30 and startswith(Name,'J')",
+			target: bson.D{
+				{Key: "age", Value: bson.M{"$gt": 30}},
+				{Key: "name", Value: bson.M{"$regex": "^J"}},
+			},
+		},
+		{
+			name:   "nested properties and literals",
+			filter: "Address/City eq 'Izmir' and Address/ZipCode ne null and CreatedAt lt 2024-01-02 and Email in ('a@b.co', 'O''Brien@b.co')",
+			target: bson.D{
+				{Key: "address.city", Value: bson.M{"$eq": "Izmir"}},
+				{Key: "address.zip_code", Value: bson.M{"$ne": nil}},
+				{Key: "created_at", Value: bson.M{"$lt": time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)}},
+				{Key: "email_address", Value: bson.M{"$in": []any{"a@b.co", "O'Brien@b.co"}}},
+			},
+		},
+		{
+			name:   "or and not",
+			filter: "(Age le 18 or Age ge 65) and not endswith(Email,'.test') and Active",
+			target: bson.D{
+				{Key: "$or", Value: bson.A{bson.M{"age": bson.M{"$lte": 18}}, bson.M{"age": bson.M{"$gte": 65}}}},
+				{Key: "email_address", Value: bson.M{"$not": bson.M{"$regex": `\.test$`}}},
+				{Key: "active", Value: bson.M{"$eq": true}},
+			},
+		},
+		{
+			name:   "tolower",
+			filter: "tolower(Name) eq 'john' and contains(tolower(Address/City),'iz') eq true",
+			target: bson.D{
+				{Key: "name", Value: bson.M{"$regex": "^john$", "$options": "i"}},
+				{Key: "address.city", Value: bson.M{"$regex": "iz", "$options": "i"}},
+			},
+		},
+		{
+			name:   "not of a logical expression",
+			filter: "not (Age gt 30 and Active eq false)",
+			target: bson.D{
+				{Key: "$nor", Value: bson.A{
+					bson.M{"$and": bson.A{bson.M{"age": bson.M{"$gt": 30}}, bson.M{"active": bson.M{"$eq": false}}}},
+				}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := kyte.FromOData(tt.filter, kyte.Source(&user))
+			testParsedFilter(t, "FromOData", f, err, tt.target)
+		})
+	}
+
+	t.Run("without source", func(t *testing.T) {
+		f, err := kyte.FromOData("Age ge 18.5 and Address/City eq 'Izmir' and Active eq true")
+		if err != nil {
+			t.Fatalf("FromOData should not return error: %v", err)
+		}
+
+		q, _ := f.Build()
+		target := bson.D{
+			{Key: "Age", Value: bson.M{"$gte": 18.5}},
+			{Key: "Address.City", Value: bson.M{"$eq": "Izmir"}},
+			{Key: "Active", Value: bson.M{"$eq": true}},
+		}
+		if !reflect.DeepEqual(q, target) {
+			t.Errorf("FromOData should return value %v, got %v", target, q)
+		}
+	})
+
+	t.Run("property paths", func(t *testing.T) {
+		type Order struct {
+			Total float64 `bson:"total"`
+		}
+
+		type Audit struct {
+			UpdatedBy string `bson:"updated_by"`
+		}
+
+		type Account struct {
+			Audit   `bson:",inline"`
+			Orders  []Order                `bson:"orders"`
+			Labels  map[string]TestAddress `bson:"labels"`
+			Profile *TestUser              `bson:"profile"`
+		}
+
+		var account Account
+		f, err := kyte.FromOData("UpdatedBy eq 'joe' and Orders/Total gt 10 and Labels/home/City eq 'Izmir' and profile/email_address eq 'a@b.co' and Profile/Address/ZipCode eq '35'",
+			kyte.Source(&account))
+
+		target := bson.D{
+			{Key: "updated_by", Value: bson.M{"$eq": "joe"}},
+			{Key: "orders.total", Value: bson.M{"$gt": 10.0}},
+			{Key: "labels.home.city", Value: bson.M{"$eq": "Izmir"}},
+			{Key: "profile.email_address", Value: bson.M{"$eq": "a@b.co"}},
+			{Key: "profile.address.zip_code", Value: bson.M{"$eq": "35"}},
+		}
+		testParsedFilter(t, "FromOData", f, err, target)
+	})
+}
+
+func TestFromOData_Errors(t *testing.T) {
+	t.Parallel()
+
+	var user TestUser
+	t.Run("syntax errors", func(t *testing.T) {
+		tests := map[string]int{
+			"Age gt":              6,
+			"(Age gt 30":          10,
+			"Name eq 'John":       8,
+			"Age gt 30 Name":      10,
+			"Age lg 30":           4,
+			"startswith(Name)":    15,
+			"Age gt 30 and # ":    14,
+			"Email in 'a@b.co'":   9,
+			"Age gt 1.2.3":        7,
+			"tolower(Name) eq 30": 17,
+			strings.Repeat("(", 100) + "Age gt 1" + strings.Repeat(")", 100): 64,
+			strings.Repeat("not ", 100) + "Active":                           256,
+		}
+
+		for filter, offset := range tests {
+			_, err := kyte.FromOData(filter, kyte.Source(&user))
+
+			var syntaxErr *kyte.SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Errorf("FromOData should return SyntaxError for %q, got %v", filter, err)
+				continue
+			}
+
+			if syntaxErr.Offset != offset {
+				t.Errorf("FromOData should return offset %d for %q, got %d", offset, filter, syntaxErr.Offset)
+			}
+		}
+	})
+
+	t.Run("unsupported constructs", func(t *testing.T) {
+		tests := map[string]string{
+			"Age add 5 gt 30":                   "operator add",
+			"length(Name) eq 4":                 "function length",
+			"Tags/any(t: t eq 'a')":             "lambda operator any",
+			"30 lt Age":                         "literal on the left side of the comparison",
+			"Age gt CreatedAt":                  "comparison of two properties",
+			"tolower(Name) gt 'j'":              "tolower with the operator gt",
+			"startswith(Name,tolower(Email))":   "startswith with a non string argument",
+			"Name eq @name":                     "comparison of two properties",
+			"geo.distance(Location, 'x') lt 10": "function geo.distance",
+		}
+
+		for filter, construct := range tests {
+			_, err := kyte.FromOData(filter, kyte.Source(&user))
+
+			var unsupportedErr *kyte.UnsupportedError
+			if !errors.As(err, &unsupportedErr) || !errors.Is(err, kyte.ErrUnsupportedExpression) {
+				t.Errorf("FromOData should return UnsupportedError for %q, got %v", filter, err)
+				continue
+			}
+
+			if unsupportedErr.Construct != construct {
+				t.Errorf("FromOData should return construct %q for %q, got %q", construct, filter, unsupportedErr.Construct)
+			}
+		}
+	})
+
+	t.Run("fields and operators", func(t *testing.T) {
+		tests := map[string]error{
+			"Age eq 'thirty'":       kyte.ErrInvalidParamValue,
+			"contains(Name,'J')":    kyte.ErrOperatorNotAllowed,
+			"Email eq 'a@b.co'":     kyte.ErrFieldNotAllowed,
+			"Address/City eq 'Izm'": nil,
+		}
+
+		for filter, target := range tests {
+			_, err := kyte.FromOData(filter, kyte.Source(&user), kyte.AllowOperators("eq"), kyte.AllowFields(&user.Age, &user.Address.City))
+			if target == nil && err != nil {
+				t.Errorf("FromOData should not return error for %q: %v", filter, err)
+			}
+			if target != nil && !errors.Is(err, target) {
+				t.Errorf("FromOData should return error %v for %q, got %v", target, filter, err)
+			}
+		}
+
+		_, err := kyte.FromOData("Password eq 'secret'", kyte.Source(&user))
+		if !errors.Is(err, kyte.ErrNotValidFieldForQuery) {
+			t.Errorf("FromOData should return error %v, got %v", kyte.ErrNotValidFieldForQuery, err)
+		}
+	})
+}
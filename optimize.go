@@ -0,0 +1,806 @@
+package kyte
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+/*
+errTautology is returned by the optimizer for the conditions that match every document, they are dropped from the query.
+*/
+var errTautology = errors.New("condition matches every document")
+
+type bound struct {
+	operator string
+	value    any
+	raw      bson.RawValue
+}
+
+/*
+fieldCondition collects the conditions of a field to merge them into one operator document.
+*/
+type fieldCondition struct {
+	field  string
+	scalar bool
+	eq     []any
+	ne     []any
+	in     []any
+	hasIn  bool
+	lower  *bound
+	upper  *bound
+	exists *bool
+	others bson.D
+	extras bson.A
+}
+
+/*
+Optimize simplifies the query before Build returns it. See [FilterBuilder.Optimize] for the details.
+*/
+func Optimize(optimize bool) OptionFunc {
+	return func(o *Options) {
+		o.optimize = optimize
+	}
+}
+
+/*
+Optimize simplifies the query before Build returns it:
+
+  - the operators of the same field are merged into one operator document and the bounds are tightened
+  - nested $and and single item $or are flattened, $or of equalities on the same field is turned into $in
+  - multiple $ne on the same field are turned into $nin and the conditions that match every document are dropped
+  - contradictions like {"age": {"$gt": 5}} and {"age": {"$lt": 3}} return [ErrContradictoryFilter]
+  - the contradictory items of $or and $nor are dropped, $or returns [ErrContradictoryFilter] only if all of its items are contradictory
+
+The equalities are reduced and the contradictions are detected only for the fields that the source says are scalar, a field
+without a source, an interface field or an array can hold an array whose different elements satisfy different conditions,
+so their operators are only merged. Global filters are kept as they are.
+
+	Filter(Source(&user)).
+		GreaterThan(&user.Age, 1).
+		GreaterThan(&user.Age, 3).
+		LessThan(&user.Age, 5).
+		Optimize() // {"age": {"$gt": 3, "$lt": 5}}
+
+	Filter().
+		Or(Filter().Equal("name", "John").Equal("name", "Doe")).
+		Optimize() // {"name": {"$in": ["John", "Doe"]}}
+*/
+func (f *FilterBuilder) Optimize() *FilterBuilder {
+	f.optimize = true
+	return f
+}
+
+/*
+scalarPaths returns the source paths that cannot hold an array, the interfaces, the slices except []byte and the arrays can.
+*/
+func (k *kyte) scalarPaths() map[string]bool {
+	paths := make(map[string]bool)
+	for path, t := range k.fieldTypes {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+
+		switch {
+		case t.Kind() == reflect.Interface, t.Kind() == reflect.Array:
+		case t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8:
+		default:
+			paths[path] = true
+		}
+	}
+	return paths
+}
+
+/*
+isScalarPath reports whether the path and all of its parents are scalar, the unknown paths are not.
+*/
+func isScalarPath(scalars map[string]bool, path string) bool {
+	for i := range path {
+		if path[i] == '.' && !scalars[path[:i]] {
+			return false
+		}
+	}
+	return scalars[path]
+}
+
+/*
+optimizeQuery returns the simplified query, errTautology is never returned from here, an empty query is returned instead.
+*/
+func optimizeQuery(query bson.D, scalars map[string]bool) (bson.D, error) {
+	entries, err := flattenQuery(query, scalars)
+	if err != nil {
+		return nil, err
+	}
+
+	result := bson.D{}
+	conditions := map[string]*fieldCondition{}
+	positions := map[string]int{}
+	var ors, extras bson.A
+
+	for _, e := range entries {
+		switch {
+		case e.Key == and:
+			// the $and of a nested query is merged into the $and of the query
+			if items, ok := toArray(e.Value); ok {
+				extras = append(extras, items...)
+				continue
+			}
+		case e.Key == or:
+			ors = append(ors, bson.D{e})
+			if len(ors) > 1 {
+				continue
+			}
+		case e.Key == nor:
+			if i, ok := positions[nor]; ok {
+				result[i].Value = append(result[i].Value.(bson.A), e.Value.(bson.A)...)
+				continue
+			}
+		case strings.HasPrefix(e.Key, "$"):
+			// the same operator can come from the spliced $and items, only the first one is kept at the top level
+			if _, ok := positions[e.Key]; ok {
+				extras = append(extras, bson.D{e})
+				continue
+			}
+		default:
+			c, ok := conditions[e.Key]
+			if !ok {
+				c = &fieldCondition{field: e.Key, scalar: isScalarPath(scalars, e.Key)}
+				conditions[e.Key] = c
+			}
+
+			if err := c.add(e.Value); err != nil {
+				return nil, err
+			}
+
+			if ok {
+				continue
+			}
+		}
+
+		if _, ok := positions[e.Key]; !ok {
+			positions[e.Key] = len(result)
+		}
+		result = append(result, e)
+	}
+
+	optimized := bson.D{}
+	for _, e := range result {
+		if c, ok := conditions[e.Key]; ok {
+			value, err := c.build()
+			if errors.Is(err, errTautology) {
+				extras = append(extras, c.extras...)
+				continue
+			}
+
+			if err != nil {
+				return nil, err
+			}
+
+			optimized = append(optimized, bson.E{Key: e.Key, Value: value})
+			extras = append(extras, c.extras...)
+			continue
+		}
+
+		optimized = append(optimized, e)
+	}
+
+	// only one $or can be at the top level, the others are kept in $and
+	if len(ors) > 1 {
+		extras = append(extras, ors...)
+		for i, e := range optimized {
+			if e.Key == or {
+				optimized = append(optimized[:i], optimized[i+1:]...)
+				break
+			}
+		}
+	}
+
+	if len(extras) > 0 {
+		optimized = append(optimized, bson.E{Key: and, Value: extras})
+	}
+
+	return optimized, nil
+}
+
+/*
+flattenQuery optimizes the logical operators of the query and splices the items of $and and single item $or into the query.
+*/
+func flattenQuery(query bson.D, scalars map[string]bool) (bson.D, error) {
+	entries := bson.D{}
+	for _, e := range query {
+		switch e.Key {
+		case and:
+			items, ok := toArray(e.Value)
+			if !ok {
+				entries = append(entries, e)
+				continue
+			}
+
+			for _, item := range items {
+				doc, ok := toDocument(item)
+				if !ok {
+					return nil, errors.Join(ErrInvalidMatchOperand, fmt.Errorf("operator: %s", and))
+				}
+
+				optimized, err := optimizeQuery(doc, scalars)
+				if err != nil {
+					return nil, err
+				}
+
+				entries = append(entries, optimized...)
+			}
+		case or:
+			optimized, err := optimizeOr(e.Value, scalars)
+			if errors.Is(err, errTautology) {
+				continue
+			}
+
+			if err != nil {
+				return nil, err
+			}
+
+			entries = append(entries, optimized...)
+		case nor:
+			optimized, err := optimizeNor(e.Value, scalars)
+			if errors.Is(err, errTautology) {
+				continue
+			}
+
+			if err != nil {
+				return nil, err
+			}
+
+			entries = append(entries, optimized...)
+		default:
+			entries = append(entries, e)
+		}
+	}
+
+	return entries, nil
+}
+
+/*
+optimizeOr returns the entries that replace the $or, the contradictory items are dropped and the equalities on the same field are merged into $in.
+*/
+func optimizeOr(value any, scalars map[string]bool) (bson.D, error) {
+	items, err := optimizeItems(or, value, scalars)
+	if err != nil {
+		return nil, err
+	}
+
+	children := bson.A{}
+	groups := map[string]int{}
+	for _, item := range items {
+		if len(item) == 0 {
+			return nil, errTautology
+		}
+
+		// {$or: [{$or: [a, b]}, c]} is the same as {$or: [a, b, c]}
+		if len(item) == 1 && item[0].Key == or {
+			nested, _ := toArray(item[0].Value)
+			for _, n := range nested {
+				doc, _ := toDocument(n)
+				children = appendOrItem(children, groups, doc)
+			}
+			continue
+		}
+
+		children = appendOrItem(children, groups, item)
+	}
+
+	switch len(children) {
+	case 0:
+		return nil, ErrContradictoryFilter
+	case 1:
+		return children[0].(bson.D), nil
+	}
+
+	return bson.D{{Key: or, Value: children}}, nil
+}
+
+/*
+appendOrItem appends the item to the $or items, the equality is merged into the $in of the previous item of the same field.
+*/
+func appendOrItem(children bson.A, groups map[string]int, item bson.D) bson.A {
+	values, ok := equalityValues(item)
+	if !ok {
+		return append(children, item)
+	}
+
+	field := item[0].Key
+	i, ok := groups[field]
+	if !ok {
+		groups[field] = len(children)
+		return append(children, item)
+	}
+
+	previous, _ := equalityValues(children[i].(bson.D))
+	children[i] = bson.D{{Key: field, Value: bson.M{in: appendDistinct(previous, values...)}}}
+	return children
+}
+
+/*
+equalityValues returns the values of the item if it is an equality or $in condition of a single field.
+*/
+func equalityValues(item bson.D) ([]any, bool) {
+	if len(item) != 1 || strings.HasPrefix(item[0].Key, "$") {
+		return nil, false
+	}
+
+	doc, ok := toDocument(item[0].Value)
+	if !ok || !isOperatorDocument(doc) {
+		if _, isRegex := item[0].Value.(primitive.Regex); isRegex || ok {
+			return nil, false
+		}
+		if _, isArray := toArray(item[0].Value); isArray {
+			return nil, false
+		}
+		return []any{item[0].Value}, true
+	}
+
+	if len(doc) != 1 {
+		return nil, false
+	}
+
+	switch doc[0].Key {
+	case eq:
+		if _, isArray := toArray(doc[0].Value); isArray {
+			return nil, false
+		}
+		return []any{doc[0].Value}, true
+	case in:
+		values, ok := toArray(doc[0].Value)
+		if !ok {
+			return nil, false
+		}
+
+		for _, v := range values {
+			if _, isRegex := v.(primitive.Regex); isRegex {
+				return nil, false
+			}
+		}
+		return values, true
+	}
+
+	return nil, false
+}
+
+/*
+optimizeNor returns the entries that replace the $nor, the contradictory items are dropped since they cannot match any document.
+*/
+func optimizeNor(value any, scalars map[string]bool) (bson.D, error) {
+	items, err := optimizeItems(nor, value, scalars)
+	if err != nil {
+		return nil, err
+	}
+
+	children := bson.A{}
+	for _, item := range items {
+		if len(item) == 0 {
+			return nil, ErrContradictoryFilter
+		}
+		children = append(children, item)
+	}
+
+	if len(children) == 0 {
+		return nil, errTautology
+	}
+
+	return bson.D{{Key: nor, Value: children}}, nil
+}
+
+/*
+optimizeItems optimizes the items of the logical operator, the contradictory items are dropped.
+*/
+func optimizeItems(operator string, value any, scalars map[string]bool) ([]bson.D, error) {
+	items, ok := toArray(value)
+	if !ok {
+		return nil, errors.Join(ErrInvalidMatchOperand, fmt.Errorf("operator: %s", operator))
+	}
+
+	optimized := []bson.D{}
+	for _, item := range items {
+		doc, ok := toDocument(item)
+		if !ok {
+			return nil, errors.Join(ErrInvalidMatchOperand, fmt.Errorf("operator: %s", operator))
+		}
+
+		query, err := optimizeQuery(doc, scalars)
+		if errors.Is(err, ErrContradictoryFilter) {
+			continue
+		}
+
+		if err != nil {
+			return nil, errors.Join(err, fmt.Errorf("operator: %s", operator))
+		}
+
+		optimized = append(optimized, query)
+	}
+
+	return optimized, nil
+}
+
+func (c *fieldCondition) add(value any) error {
+	doc, ok := toDocument(value)
+	if !ok || !isOperatorDocument(doc) {
+		if regex, ok := value.(primitive.Regex); ok {
+			c.addOther(bson.D{{Key: regx, Value: regex.Pattern}, {Key: regxOptions, Value: regex.Options}})
+			return nil
+		}
+
+		return c.addOperator(eq, value)
+	}
+
+	if regex, ok := lookupKey(doc, regx); ok {
+		options, hasOptions := lookupKey(doc, regxOptions)
+		regexDoc := bson.D{{Key: regx, Value: regex}}
+		if hasOptions {
+			regexDoc = append(regexDoc, bson.E{Key: regxOptions, Value: options})
+		}
+		c.addOther(regexDoc)
+	}
+
+	for _, e := range doc {
+		if e.Key == regx || e.Key == regxOptions {
+			continue
+		}
+
+		if err := c.addOperator(e.Key, e.Value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *fieldCondition) addOperator(operator string, value any) error {
+	switch operator {
+	case eq:
+		c.eq = appendDistinct(c.eq, value)
+	case ne:
+		c.ne = appendDistinct(c.ne, value)
+	case nin:
+		values, ok := toArray(value)
+		if !ok {
+			values = []any{value}
+		}
+		c.ne = appendDistinct(c.ne, values...)
+	case in:
+		values, ok := toArray(value)
+		if !ok {
+			values = []any{value}
+		}
+
+		switch {
+		case !c.hasIn:
+			c.in, c.hasIn = appendDistinct(nil, values...), true
+		case !c.scalar:
+			c.extras = append(c.extras, bson.D{{Key: c.field, Value: bson.M{in: values}}})
+		default:
+			c.in = intersect(c.in, values)
+		}
+	case gt, gte:
+		return c.tighten(&c.lower, operator, value, 1)
+	case lt, lte:
+		return c.tighten(&c.upper, operator, value, -1)
+	case exists:
+		v, ok := value.(bool)
+		if !ok {
+			c.addOther(bson.D{{Key: operator, Value: value}})
+			return nil
+		}
+
+		if c.exists != nil && *c.exists != v {
+			return errors.Join(ErrContradictoryFilter, fmt.Errorf("field: %s", c.field))
+		}
+		c.exists = &v
+	default:
+		c.addOther(bson.D{{Key: operator, Value: value}})
+	}
+
+	return nil
+}
+
+/*
+tighten keeps the tighter of the bounds, direction is 1 for the lower bound and -1 for the upper bound.
+*/
+func (c *fieldCondition) tighten(current **bound, operator string, value any, direction int) error {
+	raw, err := marshalRawValue(value)
+	if err != nil {
+		return err
+	}
+
+	next := &bound{operator: operator, value: value, raw: raw}
+	if *current == nil {
+		*current = next
+		return nil
+	}
+
+	if canonicalOrder((*current).raw.Type) != canonicalOrder(raw.Type) {
+		c.extras = append(c.extras, bson.D{{Key: c.field, Value: bson.M{operator: value}}})
+		return nil
+	}
+
+	switch cmp := compareValues(raw, (*current).raw) * direction; {
+	case cmp > 0:
+		*current = next
+	case cmp == 0 && (operator == gt || operator == lt):
+		*current = next
+	}
+
+	return nil
+}
+
+/*
+addOther adds the operators that are not merged, the operator that is already set is kept in $and.
+*/
+func (c *fieldCondition) addOther(doc bson.D) {
+	if _, ok := lookupKey(c.others, doc[0].Key); ok {
+		c.extras = append(c.extras, bson.D{{Key: c.field, Value: toMap(doc)}})
+		return
+	}
+
+	c.others = append(c.others, doc...)
+}
+
+/*
+build returns the merged operator document of the field, errTautology is returned if the field has no condition.
+*/
+func (c *fieldCondition) build() (any, error) {
+	if c.scalar {
+		if err := c.reduce(); err != nil {
+			return nil, errors.Join(err, fmt.Errorf("field: %s", c.field))
+		}
+	} else if len(c.eq) > 1 {
+		for _, v := range c.eq[1:] {
+			c.extras = append(c.extras, bson.D{{Key: c.field, Value: bson.M{eq: v}}})
+		}
+		c.eq = c.eq[:1]
+	}
+
+	doc := bson.M{}
+	if len(c.eq) > 0 {
+		doc[eq] = c.eq[0]
+	}
+
+	if c.hasIn {
+		doc[in] = bson.A(c.in)
+	}
+
+	if c.lower != nil {
+		doc[c.lower.operator] = c.lower.value
+	}
+
+	if c.upper != nil {
+		doc[c.upper.operator] = c.upper.value
+	}
+
+	switch len(c.ne) {
+	case 0:
+	case 1:
+		doc[ne] = c.ne[0]
+	default:
+		doc[nin] = bson.A(c.ne)
+	}
+
+	if c.exists != nil {
+		doc[exists] = *c.exists
+	}
+
+	for _, e := range c.others {
+		doc[e.Key] = e.Value
+	}
+
+	if len(doc) == 0 {
+		return nil, errTautology
+	}
+
+	return doc, nil
+}
+
+/*
+reduce tightens the conditions of a scalar field, it returns ErrContradictoryFilter if no value can satisfy them.
+*/
+func (c *fieldCondition) reduce() error {
+	if len(c.eq) > 1 {
+		return ErrContradictoryFilter
+	}
+
+	// a missing field only matches null, so {"$exists": false} conflicts with the other values
+	if c.exists != nil && !*c.exists {
+		if len(c.eq) == 1 && c.eq[0] != nil || c.hasIn && !containsValue(c.in, nil) || c.lower != nil || c.upper != nil {
+			return ErrContradictoryFilter
+		}
+	}
+
+	if c.hasIn && len(c.eq) == 0 && len(c.in) == 1 && isComparable(c.in[0]) {
+		c.eq, c.in, c.hasIn = c.in, nil, false
+	}
+
+	if len(c.eq) == 1 {
+		// null also matches the missing fields, it is kept as it is
+		if c.eq[0] == nil || !isComparable(c.eq[0]) {
+			return nil
+		}
+
+		if !c.satisfies(c.eq[0]) || (c.hasIn && !containsValue(c.in, c.eq[0])) {
+			return ErrContradictoryFilter
+		}
+
+		c.in, c.hasIn, c.lower, c.upper, c.ne, c.exists = nil, false, nil, nil, nil, nil
+		return nil
+	}
+
+	if c.hasIn {
+		for _, v := range c.in {
+			if !isComparable(v) {
+				return nil
+			}
+		}
+
+		values := []any{}
+		for _, v := range c.in {
+			if c.satisfies(v) {
+				values = append(values, v)
+			}
+		}
+
+		switch len(values) {
+		case 0:
+			return ErrContradictoryFilter
+		case 1:
+			c.eq, c.in, c.hasIn = values, nil, false
+		default:
+			c.in = values
+		}
+
+		c.lower, c.upper, c.ne = nil, nil, nil
+		if c.exists != nil && *c.exists && !containsValue(values, nil) {
+			c.exists = nil
+		}
+		return nil
+	}
+
+	if c.lower != nil && c.upper != nil && canonicalOrder(c.lower.raw.Type) == canonicalOrder(c.upper.raw.Type) {
+		switch cmp := compareValues(c.lower.raw, c.upper.raw); {
+		case cmp > 0:
+			return ErrContradictoryFilter
+		case cmp == 0 && (c.lower.operator == gt || c.upper.operator == lt):
+			return ErrContradictoryFilter
+		case cmp == 0:
+			c.eq, c.lower, c.upper = []any{c.lower.value}, nil, nil
+			return c.reduce()
+		}
+	}
+
+	return nil
+}
+
+/*
+satisfies reports whether the value satisfies the bounds and the $ne, $nin conditions of the field.
+*/
+func (c *fieldCondition) satisfies(value any) bool {
+	if containsValue(c.ne, value) {
+		return false
+	}
+
+	raw, err := marshalRawValue(value)
+	if err != nil {
+		return true
+	}
+
+	for _, b := range []*bound{c.lower, c.upper} {
+		if b == nil {
+			continue
+		}
+
+		if canonicalOrder(raw.Type) != canonicalOrder(b.raw.Type) {
+			return false
+		}
+
+		cmp := compareValues(raw, b.raw)
+		switch b.operator {
+		case gt:
+			if cmp <= 0 {
+				return false
+			}
+		case gte:
+			if cmp < 0 {
+				return false
+			}
+		case lt:
+			if cmp >= 0 {
+				return false
+			}
+		case lte:
+			if cmp > 0 {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+/*
+isComparable reports whether the value is a scalar that can be compared, regexes, documents and arrays have their own matching rules.
+*/
+func isComparable(value any) bool {
+	raw, err := marshalRawValue(value)
+	if err != nil {
+		return false
+	}
+
+	switch raw.Type {
+	case bsontype.Regex, bsontype.EmbeddedDocument, bsontype.Array:
+		return false
+	}
+	return true
+}
+
+func marshalRawValue(value any) (bson.RawValue, error) {
+	t, data, err := bson.MarshalValue(value)
+	if err != nil {
+		return bson.RawValue{}, err
+	}
+	return bson.RawValue{Type: t, Value: data}, nil
+}
+
+func sameValue(a, b any) bool {
+	rawA, errA := marshalRawValue(a)
+	rawB, errB := marshalRawValue(b)
+	if errA != nil || errB != nil {
+		return reflect.DeepEqual(a, b)
+	}
+	return compareValues(rawA, rawB) == 0
+}
+
+func containsValue(values []any, value any) bool {
+	for _, v := range values {
+		if sameValue(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func appendDistinct(values []any, items ...any) []any {
+	for _, item := range items {
+		if !containsValue(values, item) {
+			values = append(values, item)
+		}
+	}
+	return values
+}
+
+func intersect(a []any, b []any) []any {
+	values := []any{}
+	for _, v := range a {
+		if containsValue(b, v) {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+func lookupKey(doc bson.D, key string) (any, bool) {
+	for _, e := range doc {
+		if e.Key == key {
+			return e.Value, true
+		}
+	}
+	return nil, false
+}
+
+func toMap(doc bson.D) bson.M {
+	m := bson.M{}
+	for _, e := range doc {
+		m[e.Key] = e.Value
+	}
+	return m
+}
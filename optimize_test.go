@@ -0,0 +1,341 @@
+package kyte_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aaydin-tr/kyte"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestFilter_Optimize(t *testing.T) {
+	t.Parallel()
+
+	type User struct {
+		Name  string   `bson:"name"`
+		Age   int      `bson:"age"`
+		Tags  []string `bson:"tags"`
+		Extra any      `bson:"extra"`
+	}
+
+	t.Run("merge and tighten bounds", func(t *testing.T) {
+		q, err := kyte.Filter().
+			GreaterThan("age", 1).
+			GreaterThanOrEqual("age", 3).
+			GreaterThan("age", 3).
+			LessThan("age", 5).
+			Equal("name", "John").
+			Optimize().
+			Build()
+		if err != nil {
+			t.Errorf("Filter.Build should not return error: %v", err)
+		}
+
+		target := bson.D{
+			{Key: "age", Value: bson.M{"$gt": 3, "$lt": 5}},
+			{Key: "name", Value: bson.M{"$eq": "John"}},
+		}
+		if !reflect.DeepEqual(q, target) {
+			t.Errorf("Filter.Build should return value %v, got %v", target, q)
+		}
+	})
+
+	t.Run("with option", func(t *testing.T) {
+		q, err := kyte.Filter(kyte.Optimize(true)).
+			NotEqual("name", "John").
+			NotEqual("name", "Doe").
+			NotIn("name", []string{"Jane"}).
+			Build()
+		if err != nil {
+			t.Errorf("Filter.Build should not return error: %v", err)
+		}
+
+		target := bson.D{{Key: "name", Value: bson.M{"$nin": bson.A{"John", "Doe", "Jane"}}}}
+		if !reflect.DeepEqual(q, target) {
+			t.Errorf("Filter.Build should return value %v, got %v", target, q)
+		}
+	})
+
+	t.Run("or of equalities", func(t *testing.T) {
+		q, err := kyte.Filter().
+			Or(kyte.Filter().Equal("name", "John").Equal("name", "Doe").In("name", []string{"Jane"})).
+			Optimize().
+			Build()
+		if err != nil {
+			t.Errorf("Filter.Build should not return error: %v", err)
+		}
+
+		target := bson.D{{Key: "name", Value: bson.M{"$in": bson.A{"John", "Doe", "Jane"}}}}
+		if !reflect.DeepEqual(q, target) {
+			t.Errorf("Filter.Build should return value %v, got %v", target, q)
+		}
+	})
+
+	t.Run("flatten nested and", func(t *testing.T) {
+		var user User
+		q, err := kyte.Filter(kyte.Source(&user)).
+			And(kyte.Filter().And(kyte.Filter().Equal("name", "John")).In("age", []int{20, 30})).
+			Equal("age", 30).
+			Optimize().
+			Build()
+		if err != nil {
+			t.Errorf("Filter.Build should not return error: %v", err)
+		}
+
+		target := bson.D{
+			{Key: "name", Value: bson.M{"$eq": "John"}},
+			{Key: "age", Value: bson.M{"$eq": 30}},
+		}
+		if !reflect.DeepEqual(q, target) {
+			t.Errorf("Filter.Build should return value %v, got %v", target, q)
+		}
+	})
+
+	t.Run("drop tautologies", func(t *testing.T) {
+		q, err := kyte.Filter().
+			NotIn("name", []string{}).
+			Or(kyte.Filter().Equal("age", 1).And(kyte.Filter())).
+			Optimize().
+			Build()
+		if err != nil {
+			t.Errorf("Filter.Build should not return error: %v", err)
+		}
+
+		target := bson.D{}
+		if !reflect.DeepEqual(q, target) {
+			t.Errorf("Filter.Build should return value %v, got %v", target, q)
+		}
+	})
+
+	t.Run("drop contradictory or items", func(t *testing.T) {
+		var user User
+		q, err := kyte.Filter(kyte.Source(&user)).
+			Or(kyte.Filter().
+				And(kyte.Filter().GreaterThan("age", 5).LessThan("age", 3)).
+				Equal("name", "John"),
+			).
+			Optimize().
+			Build()
+		if err != nil {
+			t.Errorf("Filter.Build should not return error: %v", err)
+		}
+
+		target := bson.D{{Key: "name", Value: bson.M{"$eq": "John"}}}
+		if !reflect.DeepEqual(q, target) {
+			t.Errorf("Filter.Build should return value %v, got %v", target, q)
+		}
+	})
+
+	t.Run("drop contradictory nor items", func(t *testing.T) {
+		var user User
+		q, err := kyte.Filter(kyte.Source(&user)).
+			NOR(kyte.Filter().
+				And(kyte.Filter().Equal("age", 1).Equal("age", 2)).
+				Equal("name", "John"),
+			).
+			Optimize().
+			Build()
+		if err != nil {
+			t.Errorf("Filter.Build should not return error: %v", err)
+		}
+
+		target := bson.D{{Key: "$nor", Value: bson.A{bson.D{{Key: "name", Value: bson.M{"$eq": "John"}}}}}}
+		if !reflect.DeepEqual(q, target) {
+			t.Errorf("Filter.Build should return value %v, got %v", target, q)
+		}
+	})
+
+	t.Run("exists false with null", func(t *testing.T) {
+		var user User
+		q, err := kyte.Filter(kyte.Source(&user), kyte.Optimize(true)).
+			In(&user.Age, []any{nil, 1}).
+			Exists(&user.Age, false).
+			Build()
+		if err != nil {
+			t.Errorf("Filter.Build should not return error: %v", err)
+		}
+
+		target := bson.D{{Key: "age", Value: bson.M{"$in": bson.A{nil, 1}, "$exists": false}}}
+		if !reflect.DeepEqual(q, target) {
+			t.Errorf("Filter.Build should return value %v, got %v", target, q)
+		}
+	})
+
+	t.Run("contradictions", func(t *testing.T) {
+		var user User
+		source := kyte.Source(&user)
+		filters := map[string]interface{ Build() (bson.D, error) }{
+			"bounds":        kyte.Filter(source).GreaterThan("age", 5).LessThan("age", 3).Optimize(),
+			"equal bounds":  kyte.Filter(source).GreaterThan("age", 3).LessThanOrEqual("age", 3).Optimize(),
+			"equalities":    kyte.Filter(source).Equal("name", "John").Equal("name", "Doe").Optimize(),
+			"equal and in":  kyte.Filter(source).Equal("age", 1).In("age", []int{2, 3}).Optimize(),
+			"equal and nin": kyte.Filter(source).Equal("age", 1).NotIn("age", []int{1}).Optimize(),
+			"in and bounds": kyte.Filter(source).In("age", []int{1, 2}).GreaterThan("age", 2).Optimize(),
+			"exists":        kyte.Filter().Exists("age", true).Exists("age", false).Optimize(),
+			"exists and in": kyte.Filter(source).In(&user.Age, []int{1, 2}).Exists(&user.Age, false).Optimize(),
+			"exists and eq": kyte.Filter(source).Equal(&user.Name, "John").Exists(&user.Name, false).Optimize(),
+			"exists and gt": kyte.Filter(source).GreaterThan(&user.Age, 1).Exists(&user.Age, false).Optimize(),
+			"nor tautology": kyte.Filter().NOR(kyte.Filter().And(kyte.Filter())).Optimize(),
+			"all or items": kyte.Filter(source).
+				Or(kyte.Filter().And(kyte.Filter().Equal("age", 1).Equal("age", 2))).
+				Optimize(),
+		}
+
+		for name, f := range filters {
+			_, err := f.Build()
+			if !errors.Is(err, kyte.ErrContradictoryFilter) {
+				t.Errorf("%s: Filter.Build should return error %v, got %v", name, kyte.ErrContradictoryFilter, err)
+			}
+		}
+	})
+
+	t.Run("array fields are not reduced", func(t *testing.T) {
+		var user User
+		q, err := kyte.Filter(kyte.Source(&user), kyte.Optimize(true)).
+			Equal(&user.Tags, "go").
+			Equal(&user.Tags, "mongo").
+			GreaterThan(&user.Tags, "z").
+			LessThan(&user.Tags, "a").
+			Build()
+		if err != nil {
+			t.Errorf("Filter.Build should not return error: %v", err)
+		}
+
+		target := bson.D{
+			{Key: "tags", Value: bson.M{"$eq": "go", "$gt": "z", "$lt": "a"}},
+			{Key: "$and", Value: bson.A{bson.D{{Key: "tags", Value: bson.M{"$eq": "mongo"}}}}},
+		}
+		if !reflect.DeepEqual(q, target) {
+			t.Errorf("Filter.Build should return value %v, got %v", target, q)
+		}
+	})
+
+	t.Run("fields that can be arrays are not reduced", func(t *testing.T) {
+		var user User
+		filters := map[string]interface{ Build() (bson.D, error) }{
+			"without source": kyte.Filter(kyte.Optimize(true)).
+				Equal("tags", "a").
+				Equal("tags", "b").
+				GreaterThan("score", 5).
+				LessThan("score", 3),
+			"interface field": kyte.Filter(kyte.Source(&user), kyte.Optimize(true)).
+				Equal(&user.Extra, "a").
+				Equal(&user.Extra, "b").
+				GreaterThan(&user.Extra, 5).
+				LessThan(&user.Extra, 3),
+		}
+
+		for name, f := range filters {
+			q, err := f.Build()
+			if err != nil {
+				t.Errorf("%s: Filter.Build should not return error: %v", name, err)
+			}
+
+			if len(q) == 0 || q[len(q)-1].Key != "$and" {
+				t.Errorf("%s: Filter.Build should keep both equalities, got %v", name, q)
+			}
+		}
+
+		q, err := kyte.Filter(kyte.Optimize(true)).Equal("tags", "a").Equal("tags", "b").Build()
+		if err != nil {
+			t.Fatalf("Filter.Build should not return error: %v", err)
+		}
+
+		matched, err := kyte.Match(q, bson.M{"tags": bson.A{"a", "b"}})
+		if err != nil || !matched {
+			t.Errorf("optimized query %v should match the array, got %v %v", q, matched, err)
+		}
+	})
+
+	t.Run("single $and", func(t *testing.T) {
+		q, err := kyte.Filter(kyte.Optimize(true)).
+			And(kyte.Filter().Equal("tags", "a").Equal("tags", "b")).
+			And(kyte.Filter().Where("this.a > 1")).
+			Where("this.b > 1").
+			Equal("tags", "c").
+			Build()
+		if err != nil {
+			t.Fatalf("Filter.Build should not return error: %v", err)
+		}
+
+		target := bson.D{
+			{Key: "tags", Value: bson.M{"$eq": "a"}},
+			{Key: "$where", Value: "this.a > 1"},
+			{Key: "$and", Value: bson.A{
+				bson.D{{Key: "$where", Value: "this.b > 1"}},
+				bson.D{{Key: "tags", Value: bson.M{"$eq": "b"}}},
+				bson.D{{Key: "tags", Value: bson.M{"$eq": "c"}}},
+			}},
+		}
+		if !reflect.DeepEqual(q, target) {
+			t.Errorf("Filter.Build should return value %v, got %v", target, q)
+		}
+
+		q, err = kyte.Filter(kyte.Optimize(true)).
+			Raw(bson.D{{Key: "$and", Value: bson.A{bson.D{{Key: "tags", Value: "a"}, {Key: "tags", Value: "b"}}}}}).
+			Equal("tags", "c").
+			Build()
+		if err != nil {
+			t.Fatalf("Filter.Build should not return error: %v", err)
+		}
+
+		target = bson.D{
+			{Key: "tags", Value: bson.M{"$eq": "a"}},
+			{Key: "$and", Value: bson.A{
+				bson.D{{Key: "tags", Value: bson.M{"$eq": "b"}}},
+				bson.D{{Key: "tags", Value: bson.M{"$eq": "c"}}},
+			}},
+		}
+		if !reflect.DeepEqual(q, target) {
+			t.Errorf("Filter.Build should return value %v, got %v", target, q)
+		}
+	})
+
+	t.Run("same result as the original query", func(t *testing.T) {
+		build := func(opts ...kyte.OptionFunc) (bson.D, error) {
+			return kyte.Filter(opts...).
+				GreaterThanOrEqual("age", 18).
+				LessThan("age", 65).
+				LessThan("age", 40).
+				NotEqual("name", "Doe").
+				Or(kyte.Filter().Equal("name", "John").Equal("name", "Jane").Exists("email", true)).
+				Build()
+		}
+
+		original, err := build()
+		if err != nil {
+			t.Fatalf("Filter.Build should not return error: %v", err)
+		}
+
+		optimized, err := build(kyte.Optimize(true))
+		if err != nil {
+			t.Fatalf("Filter.Build should not return error: %v", err)
+		}
+
+		docs := []bson.M{
+			{"name": "John", "age": 20},
+			{"name": "Jane", "age": 40},
+			{"name": "Doe", "age": 30, "email": "doe@example.com"},
+			{"name": "Jack", "age": 30, "email": "jack@example.com"},
+			{"name": "Jack", "age": 30},
+			{"age": 17},
+		}
+
+		for _, doc := range docs {
+			want, err := kyte.Match(original, doc)
+			if err != nil {
+				t.Errorf("Match should not return error: %v", err)
+			}
+
+			got, err := kyte.Match(optimized, doc)
+			if err != nil {
+				t.Errorf("Match should not return error: %v", err)
+			}
+
+			if got != want {
+				t.Errorf("optimized query %v should return %v for %v, got %v", optimized, want, doc, got)
+			}
+		}
+	})
+}
@@ -0,0 +1,276 @@
+package kyte
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type pageToken struct {
+	Keys       []string `bson:"k"`
+	Directions []int    `bson:"d"`
+	Values     bson.A   `bson:"v"`
+}
+
+/*
+KeysetSort returns the sort specification of the given sort with _id as the tie-breaker, the same specification must be used
+for the query that is paginated with After or AfterToken.
+
+	kyte.KeysetSort(kyte.Sort().Desc("createdAt")) // {"createdAt": -1, "_id": 1}
+*/
+func KeysetSort(sort *SortBuilder) (bson.D, error) {
+	if sort == nil {
+		return nil, ErrNilSort
+	}
+
+	spec, err := sort.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	hasID := false
+	for _, s := range spec {
+		if _, ok := s.Value.(int); !ok {
+			return nil, errors.Join(ErrKeysetUnsupportedSort, fmt.Errorf("field: %s", s.Key))
+		}
+
+		if s.Key == UnderScoreID {
+			hasID = true
+		}
+	}
+
+	if !hasID {
+		spec = append(spec, bson.E{Key: UnderScoreID, Value: 1})
+	}
+
+	return spec, nil
+}
+
+/*
+After adds a keyset pagination condition to the filter that matches the documents which come after the given last document
+in the order of the given sort. The last document can be a struct, bson.M, bson.D or bson.Raw. The query must be sorted by [KeysetSort].
+
+	Filter().
+		Equal("status", "active").
+		After(Sort().Desc("createdAt"), lastUser)
+		// {"status": {"$eq": "active"}, "$and": [{"$or": [
+		//		{"createdAt": {"$lt": lastUser.CreatedAt}},
+		//		{"createdAt": {"$eq": lastUser.CreatedAt}, "_id": {"$gt": lastUser.ID}},
+		// ]}]}
+
+The condition is wrapped with $and like [FilterBuilder.And], so it does not collide with an $or or a field of the filter.
+*/
+func (f *FilterBuilder) After(sort *SortBuilder, last any) *FilterBuilder {
+	spec, err := KeysetSort(sort)
+	if err != nil {
+		f.kyte.setError(err)
+		return f
+	}
+
+	values, err := keysetValues(spec, last)
+	if err != nil {
+		f.kyte.setError(err)
+		return f
+	}
+
+	return f.keyset(spec, values)
+}
+
+/*
+AfterToken adds a keyset pagination condition to the filter from a page token that is created by [PageToken].
+The token must be created with the same sort and secret, otherwise an error is returned.
+
+	Filter().
+		Equal("status", "active").
+		AfterToken(Sort().Desc("createdAt"), token, secret)
+*/
+func (f *FilterBuilder) AfterToken(sort *SortBuilder, token string, secret []byte) *FilterBuilder {
+	spec, err := KeysetSort(sort)
+	if err != nil {
+		f.kyte.setError(err)
+		return f
+	}
+
+	values, err := decodePageToken(spec, token, secret)
+	if err != nil {
+		f.kyte.setError(err)
+		return f
+	}
+
+	return f.keyset(spec, values)
+}
+
+/*
+PageToken creates an opaque page token from the sort key values of the given last document. The token is signed with the given secret
+with HMAC-SHA256 so it cannot be tampered with, but it is not encrypted.
+
+	token, err := kyte.PageToken(kyte.Sort().Desc("createdAt"), lastUser, secret)
+*/
+func PageToken(sort *SortBuilder, last any, secret []byte) (string, error) {
+	if len(secret) == 0 {
+		return "", ErrEmptyPageTokenSecret
+	}
+
+	spec, err := KeysetSort(sort)
+	if err != nil {
+		return "", err
+	}
+
+	values, err := keysetValues(spec, last)
+	if err != nil {
+		return "", err
+	}
+
+	keys, directions := keysetKeys(spec)
+	payload, err := bson.Marshal(pageToken{Keys: keys, Directions: directions, Values: values})
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(sign(payload, secret)), nil
+}
+
+func decodePageToken(spec bson.D, token string, secret []byte) (bson.A, error) {
+	if len(secret) == 0 {
+		return nil, ErrEmptyPageTokenSecret
+	}
+
+	encodedPayload, encodedSignature, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, ErrInvalidPageToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return nil, ErrInvalidPageToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil {
+		return nil, ErrInvalidPageToken
+	}
+
+	if !hmac.Equal(signature, sign(payload, secret)) {
+		return nil, ErrInvalidPageToken
+	}
+
+	var t pageToken
+	if err := bson.Unmarshal(payload, &t); err != nil {
+		return nil, ErrInvalidPageToken
+	}
+
+	keys, directions := keysetKeys(spec)
+	if len(t.Keys) != len(keys) || len(t.Directions) != len(directions) || len(t.Values) != len(keys) {
+		return nil, ErrPageTokenSortMismatch
+	}
+
+	for i := range keys {
+		if t.Keys[i] != keys[i] || t.Directions[i] != directions[i] {
+			return nil, ErrPageTokenSortMismatch
+		}
+	}
+
+	return t.Values, nil
+}
+
+func sign(payload []byte, secret []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+func keysetKeys(spec bson.D) ([]string, []int) {
+	keys := make([]string, 0, len(spec))
+	directions := make([]int, 0, len(spec))
+	for _, s := range spec {
+		keys = append(keys, s.Key)
+		directions = append(directions, s.Value.(int))
+	}
+	return keys, directions
+}
+
+/*
+keysetValues returns the values of the sort keys from the given document.
+*/
+func keysetValues(spec bson.D, last any) (bson.A, error) {
+	raw, err := toRaw(last)
+	if err != nil {
+		return nil, err
+	}
+
+	values := bson.A{}
+	for _, s := range spec {
+		rawValue, err := raw.LookupErr(strings.Split(s.Key, ".")...)
+		if err != nil {
+			return nil, errors.Join(ErrKeysetMissingValue, fmt.Errorf("field: %s", s.Key))
+		}
+
+		var value any
+		if err := rawValue.Unmarshal(&value); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+
+	return values, nil
+}
+
+/*
+keyset adds the keyset condition with $and, a pending Not is rejected by And.
+*/
+func (f *FilterBuilder) keyset(spec bson.D, values bson.A) *FilterBuilder {
+	return f.And(Filter().Raw(bson.D{keysetQuery(spec, values)}))
+}
+
+/*
+keysetQuery builds the compound range condition for the given sort and values:
+
+	{"$or": [{a: {$gt: x}}, {a: {$eq: x}, b: {$gt: y}}, ...]}
+*/
+func keysetQuery(spec bson.D, values bson.A) bson.E {
+	conditions := bson.A{}
+	for i, s := range spec {
+		condition := bson.D{}
+		for j := 0; j < i; j++ {
+			condition = append(condition, bson.E{Key: spec[j].Key, Value: bson.M{eq: values[j]}})
+		}
+
+		operator := gt
+		if s.Value.(int) < 0 {
+			operator = lt
+		}
+		condition = append(condition, bson.E{Key: s.Key, Value: bson.M{operator: values[i]}})
+
+		if len(spec) == 1 {
+			return condition[0]
+		}
+		conditions = append(conditions, condition)
+	}
+
+	return bson.E{Key: or, Value: conditions}
+}
+
+/*
+toRaw converts the given document to bson.Raw.
+*/
+func toRaw(doc any) (bson.Raw, error) {
+	switch d := doc.(type) {
+	case nil:
+		return nil, ErrNilSource
+	case bson.Raw:
+		return d, d.Validate()
+	case []byte:
+		return bson.Raw(d), bson.Raw(d).Validate()
+	}
+
+	b, err := bson.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	return bson.Raw(b), nil
+}
@@ -0,0 +1,223 @@
+package kyte_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aaydin-tr/kyte"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestPagination_KeysetSort(t *testing.T) {
+	t.Parallel()
+
+	t.Run("append _id as tie-breaker", func(t *testing.T) {
+		q, err := kyte.KeysetSort(kyte.Sort().Desc("createdAt"))
+		if err != nil {
+			t.Errorf("KeysetSort should not return error: %v", err)
+		}
+
+		target := bson.D{{Key: "createdAt", Value: -1}, {Key: "_id", Value: 1}}
+		if !reflect.DeepEqual(q, target) {
+			t.Errorf("KeysetSort should return value %v, got %v", target, q)
+		}
+	})
+
+	t.Run("keep existing _id", func(t *testing.T) {
+		q, err := kyte.KeysetSort(kyte.Sort().Desc("createdAt").Desc("_id"))
+		if err != nil {
+			t.Errorf("KeysetSort should not return error: %v", err)
+		}
+
+		target := bson.D{{Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}}
+		if !reflect.DeepEqual(q, target) {
+			t.Errorf("KeysetSort should return value %v, got %v", target, q)
+		}
+	})
+
+	t.Run("text score", func(t *testing.T) {
+		_, err := kyte.KeysetSort(kyte.Sort().TextScore("score"))
+		if !errors.Is(err, kyte.ErrKeysetUnsupportedSort) {
+			t.Errorf("KeysetSort should return error %v, got %v", kyte.ErrKeysetUnsupportedSort, err)
+		}
+	})
+
+	t.Run("nil sort", func(t *testing.T) {
+		_, err := kyte.KeysetSort(nil)
+		if !errors.Is(err, kyte.ErrNilSort) {
+			t.Errorf("KeysetSort should return error %v, got %v", kyte.ErrNilSort, err)
+		}
+	})
+}
+
+func TestPagination_After(t *testing.T) {
+	t.Parallel()
+
+	type User struct {
+		ID    string `bson:"_id"`
+		Name  string `bson:"name"`
+		Age   int    `bson:"age"`
+		Email string `bson:"email"`
+	}
+
+	last := User{ID: "u2", Name: "John", Age: 30}
+
+	t.Run("struct document", func(t *testing.T) {
+		var user User
+		q, err := kyte.Filter(kyte.Source(&user)).
+			Equal(&user.Name, "John").
+			After(kyte.Sort(kyte.Source(&user)).Desc(&user.Age), last).
+			Build()
+		if err != nil {
+			t.Errorf("Filter.Build should not return error: %v", err)
+		}
+
+		target := bson.D{
+			{Key: "$and", Value: bson.A{bson.M{"$or": bson.A{
+				bson.D{{Key: "age", Value: bson.M{"$lt": int32(30)}}},
+				bson.D{{Key: "age", Value: bson.M{"$eq": int32(30)}}, {Key: "_id", Value: bson.M{"$gt": "u2"}}},
+			}}}},
+			{Key: "name", Value: bson.M{"$eq": "John"}},
+		}
+		if !reflect.DeepEqual(q, target) {
+			t.Errorf("Filter.Build should return value %v, got %v", target, q)
+		}
+	})
+
+	t.Run("raw document", func(t *testing.T) {
+		raw, err := bson.Marshal(bson.D{{Key: "_id", Value: "u2"}, {Key: "profile", Value: bson.D{{Key: "age", Value: 30}}}})
+		if err != nil {
+			t.Fatalf("bson.Marshal should not return error: %v", err)
+		}
+
+		q, err := kyte.Filter().After(kyte.Sort().Asc("profile.age"), bson.Raw(raw)).Build()
+		if err != nil {
+			t.Errorf("Filter.Build should not return error: %v", err)
+		}
+
+		target := bson.D{
+			{Key: "$and", Value: bson.A{bson.M{"$or": bson.A{
+				bson.D{{Key: "profile.age", Value: bson.M{"$gt": int32(30)}}},
+				bson.D{{Key: "profile.age", Value: bson.M{"$eq": int32(30)}}, {Key: "_id", Value: bson.M{"$gt": "u2"}}},
+			}}}},
+		}
+		if !reflect.DeepEqual(q, target) {
+			t.Errorf("Filter.Build should return value %v, got %v", target, q)
+		}
+	})
+
+	t.Run("only _id", func(t *testing.T) {
+		q, err := kyte.Filter().After(kyte.Sort().Desc("_id"), last).Build()
+		if err != nil {
+			t.Errorf("Filter.Build should not return error: %v", err)
+		}
+
+		target := bson.D{{Key: "$and", Value: bson.A{bson.M{"_id": bson.M{"$lt": "u2"}}}}}
+		if !reflect.DeepEqual(q, target) {
+			t.Errorf("Filter.Build should return value %v, got %v", target, q)
+		}
+	})
+
+	t.Run("with or and not", func(t *testing.T) {
+		q, err := kyte.Filter().
+			Or(kyte.Filter().Equal("name", "John").Equal("name", "Jane")).
+			After(kyte.Sort().Desc("_id"), last).
+			Build()
+		if err != nil {
+			t.Errorf("Filter.Build should not return error: %v", err)
+		}
+
+		target := bson.D{
+			{Key: "$or", Value: bson.A{bson.M{"name": bson.M{"$eq": "John"}}, bson.M{"name": bson.M{"$eq": "Jane"}}}},
+			{Key: "$and", Value: bson.A{bson.M{"_id": bson.M{"$lt": "u2"}}}},
+		}
+		if !reflect.DeepEqual(q, target) {
+			t.Errorf("Filter.Build should return value %v, got %v", target, q)
+		}
+
+		_, err = kyte.Filter().Not().After(kyte.Sort().Desc("_id"), last).Equal("name", "John").Build()
+		if !errors.Is(err, kyte.ErrNotWithoutOperator) {
+			t.Errorf("Filter.Build should return error %v, got %v", kyte.ErrNotWithoutOperator, err)
+		}
+	})
+
+	t.Run("missing value", func(t *testing.T) {
+		_, err := kyte.Filter().After(kyte.Sort().Asc("createdAt"), last).Build()
+		if !errors.Is(err, kyte.ErrKeysetMissingValue) {
+			t.Errorf("Filter.Build should return error %v, got %v", kyte.ErrKeysetMissingValue, err)
+		}
+	})
+}
+
+func TestPagination_PageToken(t *testing.T) {
+	t.Parallel()
+
+	type User struct {
+		ID   string `bson:"_id"`
+		Name string `bson:"name"`
+		Age  int    `bson:"age"`
+	}
+
+	secret := []byte("secret")
+	last := User{ID: "u2", Name: "John", Age: 30}
+
+	t.Run("round trip", func(t *testing.T) {
+		token, err := kyte.PageToken(kyte.Sort().Desc("age"), last, secret)
+		if err != nil {
+			t.Fatalf("PageToken should not return error: %v", err)
+		}
+
+		q, err := kyte.Filter().AfterToken(kyte.Sort().Desc("age"), token, secret).Build()
+		if err != nil {
+			t.Errorf("Filter.Build should not return error: %v", err)
+		}
+
+		target, err := kyte.Filter().After(kyte.Sort().Desc("age"), last).Build()
+		if err != nil {
+			t.Errorf("Filter.Build should not return error: %v", err)
+		}
+		if !reflect.DeepEqual(q, target) {
+			t.Errorf("Filter.Build should return value %v, got %v", target, q)
+		}
+	})
+
+	t.Run("tampered token", func(t *testing.T) {
+		token, err := kyte.PageToken(kyte.Sort().Desc("age"), last, secret)
+		if err != nil {
+			t.Fatalf("PageToken should not return error: %v", err)
+		}
+
+		payload, signature, _ := strings.Cut(token, ".")
+		tampered := strings.Replace(payload, payload[:1], string(payload[0]^1), 1) + "." + signature
+		_, err = kyte.Filter().AfterToken(kyte.Sort().Desc("age"), tampered, secret).Build()
+		if !errors.Is(err, kyte.ErrInvalidPageToken) {
+			t.Errorf("Filter.Build should return error %v, got %v", kyte.ErrInvalidPageToken, err)
+		}
+
+		_, err = kyte.Filter().AfterToken(kyte.Sort().Desc("age"), token, []byte("other")).Build()
+		if !errors.Is(err, kyte.ErrInvalidPageToken) {
+			t.Errorf("Filter.Build should return error %v, got %v", kyte.ErrInvalidPageToken, err)
+		}
+	})
+
+	t.Run("sort mismatch", func(t *testing.T) {
+		token, err := kyte.PageToken(kyte.Sort().Desc("age"), last, secret)
+		if err != nil {
+			t.Fatalf("PageToken should not return error: %v", err)
+		}
+
+		_, err = kyte.Filter().AfterToken(kyte.Sort().Asc("age"), token, secret).Build()
+		if !errors.Is(err, kyte.ErrPageTokenSortMismatch) {
+			t.Errorf("Filter.Build should return error %v, got %v", kyte.ErrPageTokenSortMismatch, err)
+		}
+	})
+
+	t.Run("empty secret", func(t *testing.T) {
+		_, err := kyte.PageToken(kyte.Sort().Desc("age"), last, nil)
+		if !errors.Is(err, kyte.ErrEmptyPageTokenSecret) {
+			t.Errorf("PageToken should return error %v, got %v", kyte.ErrEmptyPageTokenSecret, err)
+		}
+	})
+}
@@ -0,0 +1,463 @@
+package kyte
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+/*
+Parse creates a filter from a query document, it is the inverse of [FilterBuilder.Build]. The fields are validated against the source
+and the operators that kyte supports are turned into their filter methods, so the query can be validated, optimized and built again.
+
+	kyte.Parse(bson.D{{Key: "age", Value: bson.M{"$gt": 18}}, {Key: "name", Value: "John"}}, kyte.Source(&user))
+	// same as Filter(kyte.Source(&user)).GreaterThan(&user.Age, 18).Equal(&user.Name, "John")
+
+A field with a plain value is parsed as $eq. The operators that kyte does not support such as $near or $text and the operands that
+cannot be expressed with the filter methods are kept as [FilterBuilder.Raw], their fields are still validated. The whole operator
+document of a field is kept as Raw if any of its operators is, so the field is not split into two conditions.
+The items of $or and $nor with more than one condition are wrapped with $and. Like the other builders, the logical operators and
+the raw conditions are placed before the field conditions in the built query.
+
+The operators and the fields can be limited with [AllowOperators] and [AllowFields].
+*/
+func Parse(query bson.D, opts ...OptionFunc) (*FilterBuilder, error) {
+	b, f, err := newExpressionBuilder(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &queryParser{opts: b.opts, allowed: b.allowed}
+	if err := p.parseDocument(f, query); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+/*
+ParseJSON creates a filter from a query in canonical or relaxed Extended JSON, the output of [FilterBuilder.ToJSON] can be parsed back.
+
+	kyte.ParseJSON(`{"age": {"$gt": 18}, "_id": {"$oid": "5f1d7f8e9d3b2a1c4e6f8a9b"}}`, kyte.Source(&user))
+*/
+func ParseJSON(query string, opts ...OptionFunc) (*FilterBuilder, error) {
+	var doc bson.D
+	if err := bson.UnmarshalExtJSON([]byte(query), false, &doc); err != nil {
+		return nil, err
+	}
+
+	return Parse(doc, opts...)
+}
+
+/*
+queryParser adds the elements of the query documents to the filters, the nested filters are created with the same options.
+*/
+type queryParser struct {
+	opts    []OptionFunc
+	allowed *allowlist
+}
+
+func (p *queryParser) parseDocument(f *FilterBuilder, query bson.D) error {
+	for _, e := range query {
+		var err error
+		switch e.Key {
+		case and, or, nor:
+			err = p.parseLogical(f, e.Key, e.Value)
+		case where:
+			err = p.parseWhere(f, e.Value)
+		case jsonSchema:
+			err = p.parseJSONSchema(f, e.Value)
+		default:
+			if strings.HasPrefix(e.Key, "$") {
+				err = p.raw(f, "", e.Key, bson.D{e})
+			} else {
+				err = p.parseField(f, e.Key, e.Value)
+			}
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+/*
+parseLogical adds the items of the logical operator as a nested filter, the items of $and are added as they are.
+*/
+func (p *queryParser) parseLogical(f *FilterBuilder, operator string, value any) error {
+	if err := p.allowed.operator(operator); err != nil {
+		return err
+	}
+
+	items, ok := toArray(value)
+	if !ok || len(items) == 0 {
+		return invalidOperand(operator, "must be a non empty array of documents")
+	}
+
+	sub := newFilter(newOptions(p.opts))
+	for _, item := range items {
+		doc, ok := toDocument(item)
+		if !ok {
+			return invalidOperand(operator, "must be a non empty array of documents")
+		}
+
+		itemFilter := newFilter(newOptions(p.opts))
+		if err := p.parseDocument(itemFilter, doc); err != nil {
+			return err
+		}
+
+		itemFilter.dropGlobalFilters()
+		itemQuery, err := itemFilter.Build()
+		if err != nil {
+			return err
+		}
+
+		if operator == and || len(itemQuery) == 1 {
+			sub.With(itemFilter)
+		} else {
+			sub.And(itemFilter)
+		}
+	}
+
+	f.logical(operator, sub)
+	return f.kyte.err
+}
+
+func (p *queryParser) parseWhere(f *FilterBuilder, value any) error {
+	if err := p.allowed.operator(where); err != nil {
+		return err
+	}
+
+	switch js := value.(type) {
+	case string:
+		f.Where(js)
+	case primitive.JavaScript:
+		f.Where(string(js))
+	default:
+		f.Raw(bson.D{{Key: where, Value: value}})
+	}
+	return nil
+}
+
+func (p *queryParser) parseJSONSchema(f *FilterBuilder, value any) error {
+	if err := p.allowed.operator(jsonSchema); err != nil {
+		return err
+	}
+
+	schema, ok := toDocument(value)
+	if !ok {
+		return invalidOperand(jsonSchema, "must be a document")
+	}
+
+	f.JSONSchema(toMap(schema))
+	return nil
+}
+
+/*
+parseField adds the conditions of the field, the value is an operator document or the value of $eq.
+*/
+func (p *queryParser) parseField(f *FilterBuilder, field string, value any) error {
+	path, err := p.allowed.field(field)
+	if err != nil {
+		return err
+	}
+
+	if regex, ok := value.(primitive.Regex); ok {
+		return p.parseRegex(f, path, regex.Pattern, regex.Options)
+	}
+
+	doc, ok := toDocument(value)
+	if !ok || !isOperatorDocument(doc) {
+		if err := p.allowed.operator(eq); err != nil {
+			return err
+		}
+		f.Equal(path, value)
+		return nil
+	}
+
+	// the operators are parsed into a scratch filter, the field is kept as a whole if any of them is unknown so it is not in the query twice
+	scratch := &FilterBuilder{kyte: f.kyte}
+	unknown := false
+	for _, e := range doc {
+		if e.Key == regxOptions {
+			if _, ok := lookupKey(doc, regx); !ok {
+				return invalidOperand(regxOptions, "must be used with $regex")
+			}
+			continue
+		}
+
+		known, err := p.parseOperator(scratch, path, e.Key, e.Value, doc)
+		if err != nil {
+			return err
+		}
+		unknown = unknown || !known
+	}
+
+	if unknown {
+		return p.raw(f, path, "", bson.D{{Key: path, Value: doc}})
+	}
+
+	f.operations = append(f.operations, scratch.operations...)
+	return nil
+}
+
+/*
+parseOperator adds the condition of the operator, it reports false if the operator or its operand cannot be expressed with the filter methods.
+The siblings are the other operators of the field such as $options of $regex.
+*/
+func (p *queryParser) parseOperator(f *FilterBuilder, path string, operator string, value any, siblings bson.D) (bool, error) {
+	if err := p.allowed.operator(operator); err != nil {
+		return false, err
+	}
+
+	switch operator {
+	case eq, ne, gt, gte, lt, lte:
+		f.set(operator, path, value, true)
+	case in, nin, all:
+		items, ok := toArray(value)
+		if !ok {
+			return false, invalidOperand(operator, "must be an array")
+		}
+		f.set(operator, path, items, true)
+	case regx:
+		pattern, options, ok := regexOperand(value, siblings)
+		if !ok {
+			return false, invalidOperand(regx, "must be a string or a regular expression")
+		}
+		regex, err := regexp.Compile(pattern)
+		if err != nil {
+			// the patterns that go cannot compile such as some PCRE constructs are kept as they are
+			return false, nil
+		}
+
+		if options == "" {
+			f.Regex(path, regex)
+		} else {
+			f.Regex(path, regex, options)
+		}
+	case exists:
+		f.Exists(path, isTruthyValue(value))
+	case _type:
+		types, ok := bsonTypes(value)
+		if !ok {
+			return false, nil
+		}
+		f.Type(path, types...)
+	case mod:
+		items, ok := toArray(value)
+		if !ok || len(items) != 2 {
+			return false, invalidOperand(mod, "must be an array of the divisor and the remainder")
+		}
+
+		divisor, ok1 := integerOperand(items[0])
+		remainder, ok2 := integerOperand(items[1])
+		if !ok1 || !ok2 {
+			return false, nil
+		}
+		f.Mod(path, divisor, remainder)
+	case size:
+		n, ok := integerOperand(value)
+		if !ok {
+			return false, invalidOperand(size, "must be an integer")
+		}
+		f.Size(path, n)
+	case elemMatch:
+		doc, ok := toDocument(value)
+		if !ok {
+			return false, invalidOperand(elemMatch, "must be a document")
+		}
+		return true, p.parseElemMatch(f, path, doc)
+	case not:
+		doc, ok := toDocument(value)
+		if !ok || len(doc) != 1 || !isOperatorDocument(doc) || doc[0].Key == not || doc[0].Key == regxOptions {
+			// $not with a regular expression or with more than one operator cannot be expressed with Not
+			return false, nil
+		}
+
+		if doc[0].Key == regx {
+			if _, ok := lookupKey(doc, regxOptions); ok {
+				return false, nil
+			}
+		}
+
+		f.Not()
+		known, err := p.parseOperator(f, path, doc[0].Key, doc[0].Value, doc)
+		if err != nil || !known {
+			f.negate = false
+		}
+		return known, err
+	default:
+		return false, nil
+	}
+
+	return true, nil
+}
+
+func (p *queryParser) parseRegex(f *FilterBuilder, path string, pattern string, options string) error {
+	if err := p.allowed.operator(regx); err != nil {
+		return err
+	}
+
+	regex, err := regexp.Compile(pattern)
+	if err != nil {
+		return p.raw(f, path, "", bson.D{{Key: path, Value: primitive.Regex{Pattern: pattern, Options: options}}})
+	}
+
+	if options == "" {
+		f.Regex(path, regex)
+	} else {
+		f.Regex(path, regex, options)
+	}
+	return nil
+}
+
+/*
+parseElemMatch parses the document of $elemMatch against the element of the array, an operator document is a condition of the element itself.
+*/
+func (p *queryParser) parseElemMatch(f *FilterBuilder, path string, doc bson.D) error {
+	var elem any
+	if f.kyte.source != nil {
+		var err error
+		if elem, err = f.kyte.elemSource(path); err != nil {
+			return err
+		}
+	}
+
+	opts := append(p.opts[:len(p.opts):len(p.opts)], func(o *Options) {
+		o.source = elem
+	})
+	sub := newFilter(newOptions(opts))
+	elemParser := &queryParser{opts: opts, allowed: &allowlist{kyte: sub.kyte, operators: p.allowed.operators, elem: true}}
+
+	var err error
+	if isOperatorDocument(doc) && !isLogicalDocument(doc) {
+		err = elemParser.parseField(sub, Elem, doc)
+	} else {
+		err = elemParser.parseDocument(sub, doc)
+	}
+	if err != nil {
+		return err
+	}
+
+	f.ElemMatch(path, sub)
+	return nil
+}
+
+/*
+raw adds the query as it is, the field is validated if it is given and the operator is checked if it is given.
+*/
+func (p *queryParser) raw(f *FilterBuilder, path string, operator string, query bson.D) error {
+	if operator != "" {
+		if err := p.allowed.operator(operator); err != nil {
+			return err
+		}
+	}
+
+	if path != "" {
+		if _, err := f.kyte.resolveField(path); err != nil {
+			return err
+		}
+	}
+
+	f.Raw(query)
+	return nil
+}
+
+func invalidOperand(operator string, msg string) error {
+	return errors.Join(ErrInvalidMatchOperand, fmt.Errorf("operator: %s %s", operator, msg))
+}
+
+func isLogicalDocument(doc bson.D) bool {
+	for _, e := range doc {
+		if e.Key == and || e.Key == or || e.Key == nor {
+			return true
+		}
+	}
+	return false
+}
+
+func regexOperand(value any, siblings bson.D) (string, string, bool) {
+	options, _ := lookupKey(siblings, regxOptions)
+	optionsText, _ := options.(string)
+
+	switch v := value.(type) {
+	case string:
+		return v, optionsText, true
+	case primitive.Regex:
+		if optionsText == "" {
+			optionsText = v.Options
+		}
+		return v.Pattern, optionsText, true
+	}
+	return "", "", false
+}
+
+/*
+bsonTypes returns the types of the $type operand, it reports false for the aliases that have no single type such as number.
+*/
+func bsonTypes(value any) ([]bsontype.Type, bool) {
+	items, ok := toArray(value)
+	if !ok {
+		items = []any{value}
+	}
+
+	types := make([]bsontype.Type, 0, len(items))
+	for _, item := range items {
+		if alias, ok := item.(string); ok {
+			t, ok := typeAliases[alias]
+			if !ok {
+				return nil, false
+			}
+			types = append(types, t)
+			continue
+		}
+
+		code, ok := integerOperand(item)
+		if !ok || !bsontype.Type(code).IsValid() {
+			return nil, false
+		}
+		types = append(types, bsontype.Type(code))
+	}
+
+	return types, len(types) > 0
+}
+
+func integerOperand(value any) (int, bool) {
+	switch v := value.(type) {
+	case int:
+		return v, true
+	case int32:
+		return int(v), true
+	case int64:
+		return int(v), true
+	case float64:
+		if v == math.Trunc(v) {
+			return int(v), true
+		}
+	}
+	return 0, false
+}
+
+/*
+isTruthyValue reports whether the operand is true for mongo, false, null and zero are falsy.
+*/
+func isTruthyValue(value any) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case int, int32, int64, float64:
+		n, ok := integerOperand(v)
+		return !ok || n != 0
+	}
+	return true
+}
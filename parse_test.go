@@ -0,0 +1,149 @@
+package kyte_test
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/aaydin-tr/kyte"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	t.Run("round trip", func(t *testing.T) {
+		var order Order
+		filter := kyte.Filter(kyte.Source(&order)).
+			Or(kyte.Filter().Equal("customer", "John").In("scores", []int{1, 2})).
+			NOR(kyte.Filter().Size("items", 0)).
+			Equal(&order.Customer, "Jane").
+			NotEqual("items.sku", "x").
+			GreaterThan("items.price", 1.5).
+			NotIn(&order.Scores, []int{3}).
+			All(&order.Scores, []int{4, 5}).
+			Regex(&order.Customer, regexp.MustCompile("^J")).
+			Exists("items.parts", true).
+			Type(&order.Customer, bsontype.String, bsontype.Null).
+			Mod(&order.Scores, 2, 0).
+			ElemMatch(&order.Items, kyte.Filter().Equal("sku", "pen").GreaterThan("qty", 5)).
+			ElemMatch(&order.Scores, kyte.Filter().GreaterThanOrEqual(kyte.Elem, 80)).
+			Not().LessThan("items.qty", 2).
+			Where("this.scores.length > 1")
+
+		target, err := filter.ToJSON()
+		if err != nil {
+			t.Fatalf("Filter.ToJSON should not return error: %v", err)
+		}
+
+		parsed, err := kyte.ParseJSON(target, kyte.Source(&order))
+		if err != nil {
+			t.Fatalf("ParseJSON should not return error: %v", err)
+		}
+
+		q, err := parsed.ToJSON()
+		if err != nil {
+			t.Errorf("Filter.ToJSON should not return error: %v", err)
+		}
+
+		if q != target {
+			t.Errorf("ParseJSON should return value %s, got %s", target, q)
+		}
+	})
+
+	t.Run("shorthands and unknown operators", func(t *testing.T) {
+		var user TestUser
+		id := primitive.NewObjectID()
+		query := bson.D{
+			{Key: "_id", Value: id},
+			{Key: "name", Value: primitive.Regex{Pattern: "^J", Options: "i"}},
+			{Key: "age", Value: bson.D{{Key: "$gte", Value: 18}, {Key: "$bitsAllSet", Value: 3}}},
+			{Key: "tags", Value: bson.M{"$type": "number"}},
+			{Key: "$text", Value: bson.M{"$search": "coffee"}},
+			{Key: "$or", Value: bson.A{
+				bson.D{{Key: "status", Value: "active"}, {Key: "active", Value: true}},
+				bson.M{"score": bson.M{"$not": bson.M{"$gt": 5}}},
+			}},
+		}
+
+		f, err := kyte.Parse(query, kyte.Source(&user))
+
+		target := bson.D{
+			{Key: "age", Value: bson.D{{Key: "$gte", Value: 18}, {Key: "$bitsAllSet", Value: 3}}},
+			{Key: "tags", Value: bson.D{{Key: "$type", Value: "number"}}},
+			{Key: "$text", Value: bson.M{"$search": "coffee"}},
+			{Key: "$or", Value: bson.A{
+				bson.M{"$and": bson.A{bson.M{"status": bson.M{"$eq": "active"}}, bson.M{"active": bson.M{"$eq": true}}}},
+				bson.M{"score": bson.M{"$not": bson.M{"$gt": 5}}},
+			}},
+			{Key: "_id", Value: bson.M{"$eq": id}},
+			{Key: "name", Value: bson.M{"$regex": "^J", "$options": "i"}},
+		}
+		testParsedFilter(t, "Parse", f, err, target)
+	})
+
+	t.Run("mixed operators are kept together", func(t *testing.T) {
+		f, err := kyte.ParseJSON(`{"age": {"$gt": 1, "$near": [1, 2]}, "name": {"$regex": "(?<=J)o", "$options": "i", "$ne": "Jo"}}`)
+
+		target := bson.D{
+			{Key: "age", Value: bson.D{{Key: "$gt", Value: int32(1)}, {Key: "$near", Value: bson.A{int32(1), int32(2)}}}},
+			{Key: "name", Value: bson.D{{Key: "$regex", Value: "(?<=J)o"}, {Key: "$options", Value: "i"}, {Key: "$ne", Value: "Jo"}}},
+		}
+		testParsedFilter(t, "ParseJSON", f, err, target)
+	})
+
+	t.Run("optimize", func(t *testing.T) {
+		f, err := kyte.ParseJSON(`{"$or": [{"name": "John"}, {"name": "Jane"}], "age": {"$gt": 1}, "$and": [{"age": {"$gt": 3}}]}`, kyte.Optimize(true))
+
+		target := bson.D{
+			{Key: "name", Value: bson.M{"$in": bson.A{"John", "Jane"}}},
+			{Key: "age", Value: bson.M{"$gt": int32(3)}},
+		}
+		testParsedFilter(t, "ParseJSON", f, err, target)
+	})
+}
+
+func TestParse_Errors(t *testing.T) {
+	t.Parallel()
+
+	var user TestUser
+	tests := []struct {
+		name   string
+		query  string
+		opts   []kyte.OptionFunc
+		target error
+	}{
+		{name: "unknown field", query: `{"password": "secret"}`, target: kyte.ErrNotValidFieldForQuery},
+		{name: "unknown field of raw", query: `{"password": {"$near": [1, 2]}}`, target: kyte.ErrNotValidFieldForQuery},
+		{name: "unknown field in logical", query: `{"$or": [{"password": "x"}, {"name": "y"}]}`, target: kyte.ErrNotValidFieldForQuery},
+		{name: "unknown field in elemMatch", query: `{"tags": {"$elemMatch": {"$gt": "a"}}, "name": {"$elemMatch": {"x": 1}}}`, target: kyte.ErrFieldMustBeArray},
+		{name: "logical operand", query: `{"$and": {"name": "x"}}`, target: kyte.ErrInvalidMatchOperand},
+		{name: "in operand", query: `{"age": {"$in": 1}}`, target: kyte.ErrInvalidMatchOperand},
+		{name: "options without regex", query: `{"name": {"$options": "i"}}`, target: kyte.ErrInvalidMatchOperand},
+		{name: "value type", query: `{"age": {"$gt": "18"}}`, target: kyte.ErrValueTypeMismatch},
+		{name: "operator allowlist", query: `{"age": {"$gt": 18}}`, opts: []kyte.OptionFunc{kyte.AllowOperators("eq")}, target: kyte.ErrOperatorNotAllowed},
+		{name: "raw operator allowlist", query: `{"$text": {"$search": "x"}}`, opts: []kyte.OptionFunc{kyte.AllowOperators("eq")}, target: kyte.ErrOperatorNotAllowed},
+		{name: "field allowlist", query: `{"age": 18, "name": "John"}`, opts: []kyte.OptionFunc{kyte.AllowFields(&user.Age)}, target: kyte.ErrFieldNotAllowed},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := kyte.ParseJSON(tt.query, append(tt.opts, kyte.Source(&user))...)
+			if err == nil {
+				_, err = f.Build()
+			}
+
+			if !errors.Is(err, tt.target) {
+				t.Errorf("ParseJSON should return error %v, got %v", tt.target, err)
+			}
+		})
+	}
+
+	_, err := kyte.ParseJSON(`{"name": `)
+	if err == nil {
+		t.Errorf("ParseJSON should return error for invalid json")
+	}
+}
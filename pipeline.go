@@ -0,0 +1,516 @@
+package kyte
+
+import (
+	"reflect"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	match     = "$match"
+	project   = "$project"
+	group     = "$group"
+	sortStage = "$sort"
+	limit     = "$limit"
+	skip      = "$skip"
+	unwind    = "$unwind"
+	lookup    = "$lookup"
+	addFields = "$addFields"
+	count     = "$count"
+	facet     = "$facet"
+)
+
+type stage struct {
+	operator string
+	field    any
+	value    any
+}
+
+type lookupStage struct {
+	from         string
+	localField   any
+	foreignField string
+	as           string
+}
+
+/*
+PipelineBuilder builds a mongo aggregation pipeline, it is created by [Pipeline].
+*/
+type PipelineBuilder struct {
+	kyte   *kyte
+	stages []stage
+
+	// reshaped is set after the stages that change the shape of the documents, the fields are not validated against the source after them.
+	reshaped bool
+	// extras are the fields added by the stages such as $addFields or $lookup, they are valid in addition to the source fields.
+	extras []string
+}
+
+/*
+Pipeline creates a new aggregation pipeline builder. It uses the same options as [Filter], so the field references of the stages are validated against the source struct.
+Fields are validated until a stage that changes the shape of the documents such as $group, $project, $count or $facet.
+
+	kyte.Pipeline(kyte.Source(&user)).
+		Match(kyte.Filter().Equal(&user.Status, "active")).
+		Group(&user.Country, bson.D{{Key: "total", Value: bson.M{"$sum": 1}}}).
+		Sort(bson.D{{Key: "total", Value: -1}}).
+		Limit(10)
+*/
+func Pipeline(opts ...OptionFunc) *PipelineBuilder {
+	options := newOptions(opts)
+	b := &PipelineBuilder{
+		kyte: newKyteWithOptions(options),
+	}
+	b.kyte.setError(options.unsupported(0))
+	return b
+}
+
+/*
+Match use mongo [$match] stage to filter the documents with the given condition. A *FilterBuilder uses the source of the pipeline.
+
+	Pipeline().
+		Match(Filter().Equal("status", "active")) // [{"$match": {"status": {"$eq": "active"}}}]
+
+[$match]: https://www.mongodb.com/docs/manual/reference/operator/aggregation/match/
+*/
+func (p *PipelineBuilder) Match(condition Condition) *PipelineBuilder {
+	if filter, ok := condition.(*FilterBuilder); condition == nil || (ok && filter == nil) {
+		p.kyte.setError(ErrNilFilter)
+		return p
+	}
+
+	return p.add(match, nil, condition)
+}
+
+/*
+Project use mongo [$project] stage to pass along the documents with the requested fields.
+Included or excluded fields are validated, computed fields are not but their field references are.
+
+	Pipeline().
+		Project(bson.D{{Key: "name", Value: 1}, {Key: "_id", Value: 0}}) // [{"$project": {"name": 1, "_id": 0}}]
+
+[$project]: https://www.mongodb.com/docs/manual/reference/operator/aggregation/project/
+*/
+func (p *PipelineBuilder) Project(projection bson.D) *PipelineBuilder {
+	return p.add(project, nil, projection)
+}
+
+/*
+Group use mongo [$group] stage to group the documents by the given id and apply the accumulators.
+The id can be a field (string or pointer of an source struct field), nil to group all documents or an expression.
+
+	Pipeline().
+		Group("country", bson.D{{Key: "total", Value: bson.M{"$sum": 1}}}) // [{"$group": {"_id": "$country", "total": {"$sum": 1}}}]
+
+[$group]: https://www.mongodb.com/docs/manual/reference/operator/aggregation/group/
+*/
+func (p *PipelineBuilder) Group(id any, accumulators bson.D) *PipelineBuilder {
+	return p.add(group, id, accumulators)
+}
+
+/*
+Sort use mongo [$sort] stage to sort the documents by the given fields.
+
+	Pipeline().
+		Sort(bson.D{{Key: "age", Value: -1}}) // [{"$sort": {"age": -1}}]
+
+[$sort]: https://www.mongodb.com/docs/manual/reference/operator/aggregation/sort/
+*/
+func (p *PipelineBuilder) Sort(sort bson.D) *PipelineBuilder {
+	return p.add(sortStage, nil, sort)
+}
+
+/*
+Limit use mongo [$limit] stage to limit the number of documents.
+
+	Pipeline().
+		Limit(10) // [{"$limit": 10}]
+
+[$limit]: https://www.mongodb.com/docs/manual/reference/operator/aggregation/limit/
+*/
+func (p *PipelineBuilder) Limit(n int64) *PipelineBuilder {
+	if n <= 0 {
+		p.kyte.setError(ErrLimitMustBePositive)
+		return p
+	}
+
+	return p.add(limit, nil, n)
+}
+
+/*
+Skip use mongo [$skip] stage to skip the given number of documents.
+
+	Pipeline().
+		Skip(10) // [{"$skip": 10}]
+
+[$skip]: https://www.mongodb.com/docs/manual/reference/operator/aggregation/skip/
+*/
+func (p *PipelineBuilder) Skip(n int64) *PipelineBuilder {
+	if n < 0 {
+		p.kyte.setError(ErrSkipMustNotBeNegative)
+		return p
+	}
+
+	return p.add(skip, nil, n)
+}
+
+/*
+Unwind use mongo [$unwind] stage to deconstruct an array field and output a document for each element.
+
+	Pipeline().
+		Unwind("tags") // [{"$unwind": "$tags"}]
+
+	Pipeline().
+		Unwind("tags", true) // [{"$unwind": {"path": "$tags", "preserveNullAndEmptyArrays": true}}]
+
+[$unwind]: https://www.mongodb.com/docs/manual/reference/operator/aggregation/unwind/
+*/
+func (p *PipelineBuilder) Unwind(field any, preserveNullAndEmptyArrays ...bool) *PipelineBuilder {
+	return p.add(unwind, field, preserveNullAndEmptyArrays)
+}
+
+/*
+Lookup use mongo [$lookup] stage to perform a left outer join with another collection.
+The local field is validated, the foreign field belongs to the other collection so it is not. The given as field can be used by the next stages.
+
+	Pipeline().
+		Lookup("orders", "_id", "userId", "orders") // [{"$lookup": {"from": "orders", "localField": "_id", "foreignField": "userId", "as": "orders"}}]
+
+[$lookup]: https://www.mongodb.com/docs/manual/reference/operator/aggregation/lookup/
+*/
+func (p *PipelineBuilder) Lookup(from string, localField any, foreignField string, as string) *PipelineBuilder {
+	if from == "" || foreignField == "" || as == "" {
+		p.kyte.setError(ErrEmptyField)
+		return p
+	}
+
+	return p.add(lookup, localField, lookupStage{from: from, localField: localField, foreignField: foreignField, as: as})
+}
+
+/*
+AddFields use mongo [$addFields] stage to add new fields to the documents. The added fields can be used by the next stages.
+
+	Pipeline().
+		AddFields(bson.D{{Key: "total", Value: bson.M{"$sum": "$scores"}}}) // [{"$addFields": {"total": {"$sum": "$scores"}}}]
+
+[$addFields]: https://www.mongodb.com/docs/manual/reference/operator/aggregation/addFields/
+*/
+func (p *PipelineBuilder) AddFields(fields bson.D) *PipelineBuilder {
+	return p.add(addFields, nil, fields)
+}
+
+/*
+Count use mongo [$count] stage to count the documents and output it with the given field name.
+
+	Pipeline().
+		Count("total") // [{"$count": "total"}]
+
+[$count]: https://www.mongodb.com/docs/manual/reference/operator/aggregation/count/
+*/
+func (p *PipelineBuilder) Count(field string) *PipelineBuilder {
+	if field == "" {
+		p.kyte.setError(ErrEmptyField)
+		return p
+	}
+
+	return p.add(count, nil, field)
+}
+
+/*
+Facet use mongo [$facet] stage to process multiple pipelines on the same documents. The pipelines use the source of the parent pipeline.
+Consecutive Facet calls are merged into the same $facet stage.
+
+	Pipeline().
+		Facet("total", Pipeline().Count("total")).
+		Facet("items", Pipeline().Skip(10).Limit(10)) // [{"$facet": {"total": [{"$count": "total"}], "items": [{"$skip": 10}, {"$limit": 10}]}}]
+
+[$facet]: https://www.mongodb.com/docs/manual/reference/operator/aggregation/facet/
+*/
+func (p *PipelineBuilder) Facet(name string, pipeline *PipelineBuilder) *PipelineBuilder {
+	if name == "" {
+		p.kyte.setError(ErrEmptyField)
+		return p
+	}
+
+	if pipeline == nil {
+		p.kyte.setError(ErrNilPipeline)
+		return p
+	}
+
+	facets := bson.D{{Key: name, Value: pipeline}}
+	if last := len(p.stages) - 1; last >= 0 && p.stages[last].operator == facet {
+		p.stages[last].value = append(p.stages[last].value.(bson.D), facets...)
+		return p
+	}
+
+	return p.add(facet, nil, facets)
+}
+
+/*
+Build returns the pipeline as mongo.Pipeline. If there is an error, it will return nil and the first error.
+*/
+func (p *PipelineBuilder) Build() (mongo.Pipeline, error) {
+	return p.build(false, nil)
+}
+
+func (p *PipelineBuilder) build(reshaped bool, extras []string) (mongo.Pipeline, error) {
+	if p.kyte.hasErrors() {
+		return nil, p.kyte.err
+	}
+
+	p.reshaped = reshaped
+	p.extras = append([]string{}, extras...)
+
+	stages := mongo.Pipeline{}
+	for _, s := range p.stages {
+		value, err := p.buildStage(s)
+		if err != nil {
+			p.kyte.setError(err)
+			return nil, p.kyte.err
+		}
+
+		stages = append(stages, bson.D{{Key: s.operator, Value: value}})
+	}
+
+	return stages, nil
+}
+
+func (p *PipelineBuilder) buildStage(s stage) (any, error) {
+	switch s.operator {
+	case match:
+		condition := s.value.(Condition)
+		if filter, ok := condition.(*FilterBuilder); ok && p.kyte.source != nil {
+			filter.kyte.checkField = p.kyte.checkField && !p.reshaped
+			filter.kyte.options = p.kyte.options
+			filter.kyte.setSourceAndPrepareFields(p.kyte.source)
+			filter.kyte.fieldNames = append(filter.kyte.fieldNames, p.extras...)
+		}
+
+		return condition.Build()
+	case project:
+		projection := s.value.(bson.D)
+		for _, e := range projection {
+			if e.Key == UnderScoreID {
+				continue
+			}
+
+			if isProjectionFlag(e.Value) {
+				if _, err := p.fieldName(e.Key); err != nil {
+					return nil, err
+				}
+				continue
+			}
+
+			if err := p.validateExpression(e.Value); err != nil {
+				return nil, err
+			}
+		}
+
+		p.reshape()
+		return projection, nil
+	case group:
+		id := s.field
+		if id != nil {
+			var err error
+			if id, err = p.expression(id); err != nil {
+				return nil, err
+			}
+		}
+
+		groupDoc := bson.D{{Key: UnderScoreID, Value: id}}
+		for _, e := range s.value.(bson.D) {
+			if err := p.validateExpression(e.Value); err != nil {
+				return nil, err
+			}
+			groupDoc = append(groupDoc, e)
+		}
+
+		p.reshape()
+		return groupDoc, nil
+	case sortStage:
+		sortSpec := s.value.(bson.D)
+		for _, e := range sortSpec {
+			if _, ok := toDocument(e.Value); ok {
+				// $meta sorts use the name of a computed field
+				continue
+			}
+
+			if _, err := p.fieldName(e.Key); err != nil {
+				return nil, err
+			}
+		}
+
+		return sortSpec, nil
+	case unwind:
+		fieldName, err := p.fieldName(s.field)
+		if err != nil {
+			return nil, err
+		}
+
+		preserveNullAndEmptyArrays := s.value.([]bool)
+		if len(preserveNullAndEmptyArrays) == 0 {
+			return "$" + fieldName, nil
+		}
+
+		return bson.D{
+			{Key: "path", Value: "$" + fieldName},
+			{Key: "preserveNullAndEmptyArrays", Value: preserveNullAndEmptyArrays[0]},
+		}, nil
+	case lookup:
+		l := s.value.(lookupStage)
+		localField, err := p.fieldName(l.localField)
+		if err != nil {
+			return nil, err
+		}
+
+		p.extras = append(p.extras, l.as)
+		return bson.D{
+			{Key: "from", Value: l.from},
+			{Key: "localField", Value: localField},
+			{Key: "foreignField", Value: l.foreignField},
+			{Key: "as", Value: l.as},
+		}, nil
+	case addFields:
+		fields := s.value.(bson.D)
+		for _, e := range fields {
+			if err := p.validateExpression(e.Value); err != nil {
+				return nil, err
+			}
+		}
+
+		for _, e := range fields {
+			p.extras = append(p.extras, e.Key)
+		}
+		return fields, nil
+	case count:
+		p.reshape()
+		return s.value, nil
+	case facet:
+		facetDoc := bson.D{}
+		for _, f := range s.value.(bson.D) {
+			sub := f.Value.(*PipelineBuilder)
+			if p.kyte.source != nil {
+				sub.kyte.checkField = p.kyte.checkField
+				sub.kyte.options = p.kyte.options
+				sub.kyte.setSourceAndPrepareFields(p.kyte.source)
+			}
+
+			stages, err := sub.build(p.reshaped, p.extras)
+			if err != nil {
+				return nil, err
+			}
+			facetDoc = append(facetDoc, bson.E{Key: f.Key, Value: stages})
+		}
+
+		p.reshape()
+		return facetDoc, nil
+	}
+
+	return s.value, nil
+}
+
+func (p *PipelineBuilder) add(operator string, field any, value any) *PipelineBuilder {
+	p.stages = append(p.stages, stage{
+		operator: operator,
+		field:    field,
+		value:    value,
+	})
+
+	return p
+}
+
+func (p *PipelineBuilder) reshape() {
+	p.reshaped = true
+	p.extras = nil
+}
+
+/*
+fieldName resolves the given field, fields are not validated against the source after the documents are reshaped.
+*/
+func (p *PipelineBuilder) fieldName(field any) (string, error) {
+	if name, ok := field.(string); ok && name != "" && (p.reshaped || p.isExtraField(name)) {
+		return name, nil
+	}
+
+	return p.kyte.resolveField(field)
+}
+
+func (p *PipelineBuilder) isExtraField(name string) bool {
+	for _, extra := range p.extras {
+		if name == extra || strings.HasPrefix(name, extra+".") {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+expression converts the given field to a field path expression, strings that start with $ and other values are used as they are.
+*/
+func (p *PipelineBuilder) expression(field any) (any, error) {
+	switch v := field.(type) {
+	case string:
+		if strings.HasPrefix(v, "$") {
+			return v, p.validateExpression(v)
+		}
+	case fieldRef:
+	default:
+		if reflect.TypeOf(field).Kind() != reflect.Ptr {
+			return field, p.validateExpression(field)
+		}
+	}
+
+	fieldName, err := p.fieldName(field)
+	if err != nil {
+		return nil, err
+	}
+
+	return "$" + fieldName, nil
+}
+
+/*
+validateExpression validates the field paths such as "$age" that are used in the given aggregation expression. Variables such as "$$ROOT" are ignored.
+*/
+func (p *PipelineBuilder) validateExpression(expression any) error {
+	switch v := expression.(type) {
+	case string:
+		if strings.HasPrefix(v, "$") && !strings.HasPrefix(v, "$$") {
+			_, err := p.fieldName(strings.TrimPrefix(v, "$"))
+			return err
+		}
+		return nil
+	}
+
+	if doc, ok := toDocument(expression); ok {
+		for _, e := range doc {
+			if err := p.validateExpression(e.Value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if items, ok := toArray(expression); ok {
+		if _, isBytes := expression.([]byte); isBytes {
+			return nil
+		}
+
+		for _, item := range items {
+			if err := p.validateExpression(item); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func isProjectionFlag(value any) bool {
+	switch value.(type) {
+	case bool, int, int8, int16, int32, int64, float32, float64:
+		return true
+	}
+	return false
+}
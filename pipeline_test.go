@@ -0,0 +1,187 @@
+package kyte_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aaydin-tr/kyte"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func testPipelineJSON(t *testing.T, p mongo.Pipeline, target string) {
+	t.Helper()
+
+	pByte, err := bson.MarshalExtJSON(bson.D{{Key: "pipeline", Value: p}}, false, false)
+	if err != nil {
+		t.Errorf("Pipeline.Build should return a valid pipeline: %v", err)
+	}
+
+	if string(pByte) != target {
+		t.Errorf("Pipeline.Build should return value %v, got %v", target, string(pByte))
+	}
+}
+
+type matchCondition struct{}
+
+func (matchCondition) Build() (bson.D, error) {
+	return bson.D{{Key: "$text", Value: bson.M{"$search": "coffee"}}}, nil
+}
+
+func TestPipeline_Build(t *testing.T) {
+	t.Parallel()
+
+	type Order struct {
+		Total int `bson:"total"`
+	}
+
+	type User struct {
+		ID      string   `bson:"_id"`
+		Name    string   `bson:"name"`
+		Country string   `bson:"country"`
+		Status  string   `bson:"status"`
+		Tags    []string `bson:"tags"`
+		Orders  []Order  `bson:"orders"`
+	}
+
+	t.Run("without source", func(t *testing.T) {
+		p, err := kyte.Pipeline().
+			Match(kyte.Filter(kyte.IgnoreGlobalFilters()).Equal("status", "active")).
+			Unwind("tags", true).
+			Group("country", bson.D{{Key: "total", Value: bson.M{"$sum": 1}}}).
+			Sort(bson.D{{Key: "total", Value: -1}}).
+			Skip(5).
+			Limit(10).
+			Build()
+		if err != nil {
+			t.Errorf("Pipeline.Build should not return error: %v", err)
+		}
+
+		testPipelineJSON(t, p, `{"pipeline":[`+
+			`{"$match":{"status":{"$eq":"active"}}},`+
+			`{"$unwind":{"path":"$tags","preserveNullAndEmptyArrays":true}},`+
+			`{"$group":{"_id":"$country","total":{"$sum":1}}},`+
+			`{"$sort":{"total":-1}},`+
+			`{"$skip":5},`+
+			`{"$limit":10}]}`)
+	})
+
+	t.Run("with source", func(t *testing.T) {
+		var user User
+		p, err := kyte.Pipeline(kyte.Source(&user)).
+			Match(kyte.Filter(kyte.IgnoreGlobalFilters()).Equal(&user.Status, "active")).
+			Lookup("orders", &user.ID, "userId", "userOrders").
+			AddFields(bson.D{{Key: "orderCount", Value: bson.M{"$size": "$userOrders"}}}).
+			Sort(bson.D{{Key: "orderCount", Value: -1}, {Key: "name", Value: 1}}).
+			Project(bson.D{{Key: "name", Value: 1}, {Key: "orderCount", Value: 1}, {Key: "_id", Value: 0}}).
+			Build()
+		if err != nil {
+			t.Errorf("Pipeline.Build should not return error: %v", err)
+		}
+
+		testPipelineJSON(t, p, `{"pipeline":[`+
+			`{"$match":{"status":{"$eq":"active"}}},`+
+			`{"$lookup":{"from":"orders","localField":"_id","foreignField":"userId","as":"userOrders"}},`+
+			`{"$addFields":{"orderCount":{"$size":"$userOrders"}}},`+
+			`{"$sort":{"orderCount":-1,"name":1}},`+
+			`{"$project":{"name":1,"orderCount":1,"_id":0}}]}`)
+	})
+
+	t.Run("group with pointer and expression", func(t *testing.T) {
+		var user User
+		p, err := kyte.Pipeline(kyte.Source(&user)).
+			Group(&user.Country, bson.D{{Key: "names", Value: bson.M{"$push": "$name"}}}).
+			Group(bson.M{"count": bson.M{"$size": "$names"}}, bson.D{{Key: "countries", Value: bson.M{"$sum": 1}}}).
+			Group(nil, bson.D{{Key: "total", Value: bson.M{"$sum": "$countries"}}}).
+			Build()
+		if err != nil {
+			t.Errorf("Pipeline.Build should not return error: %v", err)
+		}
+
+		testPipelineJSON(t, p, `{"pipeline":[`+
+			`{"$group":{"_id":"$country","names":{"$push":"$name"}}},`+
+			`{"$group":{"_id":{"count":{"$size":"$names"}},"countries":{"$sum":1}}},`+
+			`{"$group":{"_id":null,"total":{"$sum":"$countries"}}}]}`)
+	})
+
+	t.Run("facet and count", func(t *testing.T) {
+		var user User
+		p, err := kyte.Pipeline(kyte.Source(&user)).
+			Facet("items", kyte.Pipeline().Sort(bson.D{{Key: "name", Value: 1}}).Limit(10)).
+			Facet("total", kyte.Pipeline().Count("total")).
+			Build()
+		if err != nil {
+			t.Errorf("Pipeline.Build should not return error: %v", err)
+		}
+
+		testPipelineJSON(t, p, `{"pipeline":[`+
+			`{"$facet":{"items":[{"$sort":{"name":1}},{"$limit":10}],"total":[{"$count":"total"}]}}]}`)
+	})
+
+	t.Run("custom condition", func(t *testing.T) {
+		p, err := kyte.Pipeline().
+			Match(kyte.Condition(matchCondition{})).
+			Build()
+		if err != nil {
+			t.Errorf("Pipeline.Build should not return error: %v", err)
+		}
+
+		testPipelineJSON(t, p, `{"pipeline":[{"$match":{"$text":{"$search":"coffee"}}}]}`)
+	})
+
+	t.Run("invalid field", func(t *testing.T) {
+		var user User
+		pipelines := []interface {
+			Build() (mongo.Pipeline, error)
+		}{
+			kyte.Pipeline(kyte.Source(&user)).Sort(bson.D{{Key: "age", Value: 1}}),
+			kyte.Pipeline(kyte.Source(&user)).Unwind("items"),
+			kyte.Pipeline(kyte.Source(&user)).Group("age", nil),
+			kyte.Pipeline(kyte.Source(&user)).Group(nil, bson.D{{Key: "total", Value: bson.M{"$sum": "$age"}}}),
+			kyte.Pipeline(kyte.Source(&user)).Project(bson.D{{Key: "age", Value: 1}}),
+			kyte.Pipeline(kyte.Source(&user)).Match(kyte.Filter().Equal("age", 1)),
+			kyte.Pipeline(kyte.Source(&user)).Facet("items", kyte.Pipeline().Unwind("items")),
+		}
+
+		for _, p := range pipelines {
+			_, err := p.Build()
+			if !errors.Is(err, kyte.ErrNotValidFieldForQuery) {
+				t.Errorf("Pipeline.Build should return error %v, got %v", kyte.ErrNotValidFieldForQuery, err)
+			}
+		}
+	})
+
+	t.Run("fields are not validated after reshaping", func(t *testing.T) {
+		var user User
+		_, err := kyte.Pipeline(kyte.Source(&user)).
+			Group(&user.Country, bson.D{{Key: "total", Value: bson.M{"$sum": 1}}}).
+			Match(kyte.Filter().GreaterThan("total", 10)).
+			Sort(bson.D{{Key: "total", Value: -1}}).
+			Build()
+		if err != nil {
+			t.Errorf("Pipeline.Build should not return error: %v", err)
+		}
+	})
+
+	t.Run("first error wins", func(t *testing.T) {
+		_, err := kyte.Pipeline().Limit(0).Skip(-1).Build()
+		if err != kyte.ErrLimitMustBePositive {
+			t.Errorf("Pipeline.Build should return error %v, got %v", kyte.ErrLimitMustBePositive, err)
+		}
+
+		_, err = kyte.Pipeline().Skip(-1).Match(nil).Build()
+		if err != kyte.ErrSkipMustNotBeNegative {
+			t.Errorf("Pipeline.Build should return error %v, got %v", kyte.ErrSkipMustNotBeNegative, err)
+		}
+
+		_, err = kyte.Pipeline().Match(nil).Build()
+		if err != kyte.ErrNilFilter {
+			t.Errorf("Pipeline.Build should return error %v, got %v", kyte.ErrNilFilter, err)
+		}
+
+		_, err = kyte.Pipeline().Facet("items", nil).Build()
+		if err != kyte.ErrNilPipeline {
+			t.Errorf("Pipeline.Build should return error %v, got %v", kyte.ErrNilPipeline, err)
+		}
+	})
+}
@@ -0,0 +1,224 @@
+package kyte
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	positional    = "$"
+	allPositional = "$[]"
+)
+
+var arrayFilterIdentifier = regexp.MustCompile(`^[a-z][a-zA-Z0-9]*$`)
+
+/*
+PositionalPath is a path to an element of an array field, it is created by [Index], [Positional], [AllPositional] and
+[FilteredPositional] and resolved to a dotted path such as items.0.price by the builders.
+*/
+type PositionalPath struct {
+	array   any
+	segment string
+	field   any
+	err     error
+}
+
+/*
+Index returns a path to the element at the given index of the array field, the optional field is a field of the element.
+The field can be a string relative to the element or a pointer to a field of an element of the source array.
+
+	kyte.Index(&order.Items, 0, &order.Items[0].Price) // items.0.price
+	kyte.Index(&order.Items, 0, "price")               // items.0.price
+	kyte.Index(&order.Items, 1)                        // items.1
+*/
+func Index(array any, index int, field ...any) PositionalPath {
+	p := newPositionalPath(array, strconv.Itoa(index), field)
+	if index < 0 {
+		p.err = ErrIndexMustNotBeNegative
+	}
+	return p
+}
+
+/*
+Positional returns a path with the [$] operator, it updates the first element that matches the query.
+
+	kyte.Positional(&order.Items, "qty") // items.$.qty
+
+[$]: https://www.mongodb.com/docs/manual/reference/operator/update/positional/
+*/
+func Positional(array any, field ...any) PositionalPath {
+	return newPositionalPath(array, positional, field)
+}
+
+/*
+AllPositional returns a path with the [$[]] operator, it updates all the elements of the array.
+
+	kyte.AllPositional(&order.Items, "qty") // items.$[].qty
+
+[$[]]: https://www.mongodb.com/docs/manual/reference/operator/update/positional-all/
+*/
+func AllPositional(array any, field ...any) PositionalPath {
+	return newPositionalPath(array, allPositional, field)
+}
+
+/*
+FilteredPositional returns a path with the [$[<identifier>]] operator, it updates the elements that match the array filter of the identifier.
+The array filter is set with [UpdateBuilder.ArrayFilter].
+
+	kyte.FilteredPositional(&order.Items, "elem", "qty") // items.$[elem].qty
+
+[$[<identifier>]]: https://www.mongodb.com/docs/manual/reference/operator/update/positional-filtered/
+*/
+func FilteredPositional(array any, identifier string, field ...any) PositionalPath {
+	p := newPositionalPath(array, "$["+identifier+"]", field)
+	if !arrayFilterIdentifier.MatchString(identifier) {
+		p.err = errors.Join(ErrInvalidArrayFilterIdentifier, fmt.Errorf("identifier: %q", identifier))
+	}
+	return p
+}
+
+func newPositionalPath(array any, segment string, field []any) PositionalPath {
+	p := PositionalPath{array: array, segment: segment}
+	if len(field) > 1 {
+		p.err = ErrPositionalFieldCount
+	}
+	if len(field) > 0 {
+		p.field = field[0]
+	}
+	return p
+}
+
+func (p PositionalPath) fieldPath(k *kyte) (string, error) {
+	if p.err != nil {
+		return "", p.err
+	}
+
+	if p.array == nil {
+		return "", ErrNilField
+	}
+
+	arrayPath, err := k.getFieldName(p.array)
+	if err != nil {
+		return "", err
+	}
+
+	if t, ok := k.fieldType(normalizePath(arrayPath)); ok && k.checkField && !isArrayType(t) {
+		return "", errors.Join(ErrFieldMustBeArray, fmt.Errorf("field: %s", arrayPath))
+	}
+
+	path := arrayPath + "." + p.segment
+	if p.field == nil {
+		return path, nil
+	}
+
+	if field, ok := p.field.(string); ok {
+		if field == "" {
+			return "", ErrEmptyField
+		}
+		return path + "." + field, nil
+	}
+
+	fieldPath, err := k.getFieldName(p.field)
+	if err != nil {
+		return "", err
+	}
+
+	elemPath, ok := strings.CutPrefix(fieldPath, normalizePath(arrayPath)+".")
+	if !ok {
+		return "", errors.Join(ErrNotValidFieldForQuery, fmt.Errorf("field: %s is not a field of the array: %s", fieldPath, arrayPath))
+	}
+
+	return path + "." + elemPath, nil
+}
+
+/*
+isPositionalSegment reports whether the path segment is an array index or a positional operator.
+*/
+func isPositionalSegment(segment string) bool {
+	if segment == positional || segment == allPositional {
+		return true
+	}
+
+	if identifier, ok := arrayFilterSegment(segment); ok {
+		return arrayFilterIdentifier.MatchString(identifier)
+	}
+
+	if segment == "" {
+		return false
+	}
+
+	for _, r := range segment {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func arrayFilterSegment(segment string) (string, bool) {
+	if !strings.HasPrefix(segment, "$[") || !strings.HasSuffix(segment, "]") || len(segment) < 3 {
+		return "", false
+	}
+	return segment[2 : len(segment)-1], true
+}
+
+/*
+normalizePath removes the array indexes and the positional operators from the path, items.$[elem].qty becomes items.qty.
+*/
+func normalizePath(path string) string {
+	segments := strings.Split(path, ".")
+	normalized := make([]string, 0, len(segments))
+	for _, segment := range segments {
+		if !isPositionalSegment(segment) {
+			normalized = append(normalized, segment)
+		}
+	}
+	return strings.Join(normalized, ".")
+}
+
+/*
+positionalField returns the path without its positional segments, every positional segment must follow an array field.
+*/
+func (k *kyte) positionalField(path string) (string, bool) {
+	segments := strings.Split(path, ".")
+	normalized := make([]string, 0, len(segments))
+	found := false
+
+	for _, segment := range segments {
+		if !isPositionalSegment(segment) {
+			normalized = append(normalized, segment)
+			continue
+		}
+
+		t, ok := k.fieldType(strings.Join(normalized, "."))
+		if len(normalized) == 0 || !ok || !isArrayType(t) {
+			return "", false
+		}
+		found = true
+	}
+
+	return strings.Join(normalized, "."), found
+}
+
+/*
+arrayFilterIdentifiers returns the identifiers of the filtered positional operators of the path with the normalized path of their arrays.
+*/
+func arrayFilterIdentifiers(path string) map[string]string {
+	identifiers := map[string]string{}
+	segments := strings.Split(path, ".")
+	for i, segment := range segments {
+		if identifier, ok := arrayFilterSegment(segment); ok && identifier != "" {
+			identifiers[identifier] = normalizePath(strings.Join(segments[:i], "."))
+		}
+	}
+	return identifiers
+}
+
+func isArrayType(t reflect.Type) bool {
+	t = indirectType(t)
+	return t.Kind() == reflect.Slice || t.Kind() == reflect.Array
+}
@@ -0,0 +1,158 @@
+package kyte_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aaydin-tr/kyte"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type OrderItem struct {
+	Sku   string   `bson:"sku"`
+	Price float64  `bson:"price"`
+	Qty   int      `bson:"qty"`
+	Parts []string `bson:"parts"`
+}
+
+type Order struct {
+	Customer string      `bson:"customer"`
+	Items    []OrderItem `bson:"items"`
+	Scores   []int       `bson:"scores"`
+}
+
+func TestPositionalPaths(t *testing.T) {
+	t.Parallel()
+
+	t.Run("filter with index", func(t *testing.T) {
+		order := Order{Items: []OrderItem{{}}}
+		paths := []kyte.PositionalPath{kyte.Index(&order.Items, 0, &order.Items[0].Price), kyte.Index(&order.Items, 1, "sku")}
+		q, err := kyte.Filter(kyte.Source(&order)).
+			Equal(paths[0], 10.5).
+			Equal(paths[1], "pen").
+			GreaterThan("items.2.qty", 1).
+			Build()
+		if err != nil {
+			t.Errorf("Filter.Build should not return error: %v", err)
+		}
+
+		target := bson.D{
+			{Key: "items.0.price", Value: bson.M{"$eq": 10.5}},
+			{Key: "items.1.sku", Value: bson.M{"$eq": "pen"}},
+			{Key: "items.2.qty", Value: bson.M{"$gt": 1}},
+		}
+		if !reflect.DeepEqual(q, target) {
+			t.Errorf("Filter.Build should return value %v, got %v", target, q)
+		}
+	})
+
+	t.Run("invalid positional paths", func(t *testing.T) {
+		var order Order
+		tests := []struct {
+			field any
+			err   error
+		}{
+			{field: "customer.0", err: kyte.ErrNotValidFieldForQuery},
+			{field: "items.0.color", err: kyte.ErrNotValidFieldForQuery},
+			{field: kyte.Index(&order.Items, -1), err: kyte.ErrIndexMustNotBeNegative},
+			{field: kyte.Index(&order.Customer, 0), err: kyte.ErrFieldMustBeArray},
+			{field: kyte.Index(&order.Items, 0, &order.Customer), err: kyte.ErrNotValidFieldForQuery},
+			{field: kyte.Index(&order.Items, 0, "sku", "qty"), err: kyte.ErrPositionalFieldCount},
+			{field: kyte.FilteredPositional(&order.Items, "Elem"), err: kyte.ErrInvalidArrayFilterIdentifier},
+		}
+
+		for _, test := range tests {
+			_, err := kyte.Filter(kyte.Source(&order)).Exists(test.field, true).Build()
+			if !errors.Is(err, test.err) {
+				t.Errorf("Filter.Build should return error %v for %v, got %v", test.err, test.field, err)
+			}
+		}
+	})
+
+	t.Run("update with positional operators", func(t *testing.T) {
+		order := Order{Items: []OrderItem{{}}}
+		q, err := kyte.Update(kyte.Source(&order)).
+			Set(kyte.Positional(&order.Items, "qty"), 1).
+			Inc(kyte.AllPositional(&order.Items, "price"), 2.5).
+			Set(kyte.AllPositional(&order.Items, kyte.Index(&order.Items[0].Parts, 0)), "lid").
+			Build()
+		if err != nil {
+			t.Errorf("Update.Build should not return error: %v", err)
+		}
+
+		testUpdateJSON(t, q, `{"$set":{"items.$.qty":1,"items.$[].parts.0":"lid"},"$inc":{"items.$[].price":2.5}}`)
+	})
+}
+
+func TestUpdate_ArrayFilter(t *testing.T) {
+	t.Parallel()
+
+	t.Run("array filters", func(t *testing.T) {
+		var order Order
+		u := kyte.Update(kyte.Source(&order)).
+			Set(kyte.FilteredPositional(&order.Items, "item", "qty"), 0).
+			Set(kyte.FilteredPositional(&order.Scores, "score"), 100).
+			ArrayFilter("item", kyte.Filter().LessThan("qty", 5).Or(kyte.Filter().Equal("sku", "pen"))).
+			ArrayFilter("score", kyte.Filter().GreaterThanOrEqual(kyte.Elem, 90))
+
+		q, err := u.Build()
+		if err != nil {
+			t.Errorf("Update.Build should not return error: %v", err)
+		}
+		testUpdateJSON(t, q, `{"$set":{"items.$[item].qty":0,"scores.$[score]":100}}`)
+
+		filters, err := u.ArrayFilters()
+		if err != nil {
+			t.Errorf("Update.ArrayFilters should not return error: %v", err)
+		}
+
+		target := []any{
+			bson.D{
+				{Key: "$or", Value: bson.A{bson.M{"item.sku": bson.M{"$eq": "pen"}}}},
+				{Key: "item.qty", Value: bson.M{"$lt": 5}},
+			},
+			bson.D{{Key: "score", Value: bson.M{"$gte": 90}}},
+		}
+		if !reflect.DeepEqual(filters, target) {
+			t.Errorf("Update.ArrayFilters should return value %v, got %v", target, filters)
+		}
+	})
+
+	t.Run("array filter is validated against the element", func(t *testing.T) {
+		var order Order
+		_, err := kyte.Update(kyte.Source(&order)).
+			Set(kyte.FilteredPositional(&order.Items, "item", "qty"), 0).
+			ArrayFilter("item", kyte.Filter().LessThan("customer", 5)).
+			ArrayFilters()
+		if !errors.Is(err, kyte.ErrNotValidFieldForQuery) {
+			t.Errorf("Update.ArrayFilters should return error %v, got %v", kyte.ErrNotValidFieldForQuery, err)
+		}
+	})
+
+	t.Run("missing and unused array filters", func(t *testing.T) {
+		var order Order
+		_, err := kyte.Update(kyte.Source(&order)).
+			Set(kyte.FilteredPositional(&order.Items, "item", "qty"), 0).
+			Set(kyte.FilteredPositional(&order.Scores, "score"), 0).
+			ArrayFilter("item", kyte.Filter().LessThan("qty", 5)).
+			Build()
+		if !errors.Is(err, kyte.ErrMissingArrayFilter) {
+			t.Errorf("Update.Build should return error %v, got %v", kyte.ErrMissingArrayFilter, err)
+		}
+
+		_, err = kyte.Update(kyte.Source(&order)).
+			Set(kyte.FilteredPositional(&order.Items, "item", "qty"), 0).
+			ArrayFilter("item", kyte.Filter().LessThan("qty", 5)).
+			ArrayFilter("other", kyte.Filter().LessThan("qty", 5)).
+			Build()
+		if !errors.Is(err, kyte.ErrUnusedArrayFilter) {
+			t.Errorf("Update.Build should return error %v, got %v", kyte.ErrUnusedArrayFilter, err)
+		}
+
+		_, err = kyte.Update().Set("items.$[item].qty", 0).ArrayFilter("1item", kyte.Filter()).Build()
+		if !errors.Is(err, kyte.ErrInvalidArrayFilterIdentifier) {
+			t.Errorf("Update.Build should return error %v, got %v", kyte.ErrInvalidArrayFilterIdentifier, err)
+		}
+	})
+}
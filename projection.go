@@ -0,0 +1,210 @@
+package kyte
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	include = "include"
+	exclude = "exclude"
+)
+
+/*
+ProjectionBuilder builds a mongo projection document, it is created by [Projection] or [ProjectionFromStruct].
+*/
+type ProjectionBuilder struct {
+	kyte       *kyte
+	operations []operation
+}
+
+/*
+Projection creates a new projection builder that can be used with options.Find().SetProjection. It uses the same options as [Filter], so the fields are validated against the source struct.
+
+	kyte.Projection(kyte.Source(&user)).
+		Include(&user.Name, &user.Age).
+		Exclude(&user.ID).
+		Build() // {"name": 1, "age": 1, "_id": 0}
+*/
+func Projection(opts ...OptionFunc) *ProjectionBuilder {
+	options := newOptions(opts)
+	b := &ProjectionBuilder{
+		kyte: newKyteWithOptions(options),
+	}
+	b.kyte.setError(options.unsupported(0))
+	return b
+}
+
+/*
+ProjectionFromStruct creates an inclusion projection from the bson tags of the given struct, it is useful to fetch only the fields of a smaller struct.
+The fields are validated against the source struct if it is given.
+
+	type UserSummary struct {
+		Name string `bson:"name"`
+		Age  int    `bson:"age"`
+	}
+
+	kyte.ProjectionFromStruct(&UserSummary{}, kyte.Source(&user)).
+		Build() // {"name": 1, "age": 1}
+*/
+func ProjectionFromStruct(dto any, opts ...OptionFunc) *ProjectionBuilder {
+	p := Projection(opts...)
+	if dto == nil {
+		p.kyte.setError(ErrNilSource)
+		return p
+	}
+
+	v := reflect.ValueOf(dto)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+
+	if v.Kind() != reflect.Struct {
+		p.kyte.setError(ErrNotStruct)
+		return p
+	}
+
+	fields, _, err := (&schemaBuilder{options: p.kyte.options}).documentFields(v.Type())
+	if err != nil {
+		p.kyte.setError(err)
+		return p
+	}
+
+	for _, field := range fields {
+		p.Include(field.name)
+	}
+
+	return p
+}
+
+/*
+Include adds the given fields to the projection as included fields.
+
+	Projection().
+		Include("name", "age") // {"name": 1, "age": 1}
+*/
+func (p *ProjectionBuilder) Include(fields ...any) *ProjectionBuilder {
+	for _, field := range fields {
+		p.set(include, field, 1)
+	}
+	return p
+}
+
+/*
+Exclude adds the given fields to the projection as excluded fields. Inclusion and exclusion cannot be mixed except _id field.
+
+	Projection().
+		Exclude("password", "_id") // {"password": 0, "_id": 0}
+*/
+func (p *ProjectionBuilder) Exclude(fields ...any) *ProjectionBuilder {
+	for _, field := range fields {
+		p.set(exclude, field, 0)
+	}
+	return p
+}
+
+/*
+Slice use mongo [$slice] projection operator to limit the number of array elements. If skip is given, the elements are skipped first.
+
+	Projection().
+		Slice("tags", 5) // {"tags": {"$slice": 5}}
+
+	Projection().
+		Slice("tags", 5, 10) // {"tags": {"$slice": [10, 5]}}
+
+[$slice]: https://www.mongodb.com/docs/manual/reference/operator/projection/slice/
+*/
+func (p *ProjectionBuilder) Slice(field any, limit int, skip ...int) *ProjectionBuilder {
+	if len(skip) == 0 {
+		return p.set(slice, field, bson.M{slice: limit})
+	}
+
+	return p.set(slice, field, bson.M{slice: bson.A{skip[0], limit}})
+}
+
+/*
+ElemMatch use mongo [$elemMatch] projection operator to return only the first array element that matches the given filter.
+Like the filter operator, the filter is scoped to the array element.
+
+	Projection().
+		ElemMatch("items", Filter().Equal("name", "pen")) // {"items": {"$elemMatch": {"name": {"$eq": "pen"}}}}
+
+[$elemMatch]: https://www.mongodb.com/docs/manual/reference/operator/projection/elemMatch/
+*/
+func (p *ProjectionBuilder) ElemMatch(field any, filter *FilterBuilder) *ProjectionBuilder {
+	if filter == nil {
+		p.kyte.setError(ErrNilFilter)
+		return p
+	}
+
+	query, err := p.kyte.buildElemFilter(field, filter)
+	if err != nil {
+		p.kyte.setError(err)
+		return p
+	}
+
+	return p.set(elemMatch, field, bson.M{elemMatch: query})
+}
+
+/*
+Build returns the projection as bson.D. If there is an error, it will return nil and the first error.
+*/
+func (p *ProjectionBuilder) Build() (bson.D, error) {
+	if p.kyte.hasErrors() {
+		return nil, p.kyte.err
+	}
+
+	query := bson.D{}
+	paths := []string{}
+	hasInclude, hasExclude := false, false
+	for _, opt := range p.operations {
+		var fieldName string
+		var err error
+		if opt.field == UnderScoreID {
+			// _id field always exists in the documents
+			fieldName = UnderScoreID
+		} else {
+			fieldName, err = p.kyte.resolveField(opt.field)
+		}
+
+		if err != nil {
+			p.kyte.setError(err)
+			return nil, err
+		}
+
+		if conflict, ok := conflictingPath(paths, fieldName); ok {
+			err := errors.Join(ErrProjectionPathCollision, fmt.Errorf("path: %s collides with: %s", fieldName, conflict))
+			p.kyte.setError(err)
+			return nil, err
+		}
+		paths = append(paths, fieldName)
+
+		if fieldName != UnderScoreID {
+			hasInclude = hasInclude || opt.operator == include
+			hasExclude = hasExclude || opt.operator == exclude
+		}
+
+		if hasInclude && hasExclude {
+			p.kyte.setError(ErrProjectionMixed)
+			return nil, ErrProjectionMixed
+		}
+
+		query = append(query, bson.E{Key: fieldName, Value: opt.value})
+	}
+
+	return query, nil
+}
+
+func (p *ProjectionBuilder) set(operator string, field any, value any) *ProjectionBuilder {
+	p.operations = append(p.operations, operation{
+		operator:        operator,
+		field:           field,
+		value:           value,
+		isFieldRequired: true,
+	})
+
+	return p
+}
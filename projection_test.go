@@ -0,0 +1,156 @@
+package kyte_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aaydin-tr/kyte"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestProjection_Build(t *testing.T) {
+	t.Parallel()
+
+	type Item struct {
+		Name string `bson:"name"`
+	}
+
+	type User struct {
+		ID       string   `bson:"_id"`
+		Name     string   `bson:"name"`
+		Age      int      `bson:"age"`
+		Password string   `bson:"password"`
+		Tags     []string `bson:"tags"`
+		Items    []Item   `bson:"items"`
+	}
+
+	t.Run("without source", func(t *testing.T) {
+		q, err := kyte.Projection().Include("name", "age").Exclude("_id").Build()
+		if err != nil {
+			t.Errorf("Projection.Build should not return error: %v", err)
+		}
+
+		target := bson.D{{Key: "name", Value: 1}, {Key: "age", Value: 1}, {Key: "_id", Value: 0}}
+		if !reflect.DeepEqual(q, target) {
+			t.Errorf("Projection.Build should return value %v, got %v", target, q)
+		}
+	})
+
+	t.Run("with source", func(t *testing.T) {
+		var user User
+		q, err := kyte.Projection(kyte.Source(&user)).
+			Include(&user.Name, &user.Age).
+			Exclude(&user.ID).
+			Slice(&user.Tags, 5).
+			ElemMatch(&user.Items, kyte.Filter().Equal("name", "pen")).
+			Build()
+		if err != nil {
+			t.Errorf("Projection.Build should not return error: %v", err)
+		}
+
+		target := bson.D{
+			{Key: "name", Value: 1},
+			{Key: "age", Value: 1},
+			{Key: "_id", Value: 0},
+			{Key: "tags", Value: bson.M{"$slice": 5}},
+			{Key: "items", Value: bson.M{"$elemMatch": bson.D{{Key: "name", Value: bson.M{"$eq": "pen"}}}}},
+		}
+		if !reflect.DeepEqual(q, target) {
+			t.Errorf("Projection.Build should return value %v, got %v", target, q)
+		}
+	})
+
+	t.Run("slice with skip", func(t *testing.T) {
+		q, err := kyte.Projection().Exclude("password").Slice("tags", 5, 10).Build()
+		if err != nil {
+			t.Errorf("Projection.Build should not return error: %v", err)
+		}
+
+		target := bson.D{{Key: "password", Value: 0}, {Key: "tags", Value: bson.M{"$slice": bson.A{10, 5}}}}
+		if !reflect.DeepEqual(q, target) {
+			t.Errorf("Projection.Build should return value %v, got %v", target, q)
+		}
+	})
+
+	t.Run("from struct", func(t *testing.T) {
+		type UserSummary struct {
+			Name     string `bson:"name"`
+			Age      int    `bson:"age,omitempty"`
+			Internal string `bson:"-"`
+			hidden   string `bson:"hidden"`
+		}
+
+		var user User
+		q, err := kyte.ProjectionFromStruct(&UserSummary{hidden: ""}, kyte.Source(&user)).Exclude("_id").Build()
+		if err != nil {
+			t.Errorf("ProjectionFromStruct should not return error: %v", err)
+		}
+
+		target := bson.D{{Key: "name", Value: 1}, {Key: "age", Value: 1}, {Key: "_id", Value: 0}}
+		if !reflect.DeepEqual(q, target) {
+			t.Errorf("ProjectionFromStruct should return value %v, got %v", target, q)
+		}
+	})
+
+	t.Run("from struct with driver naming", func(t *testing.T) {
+		type Audit struct {
+			CreatedAt string `bson:"created_at"`
+		}
+
+		type UserSummary struct {
+			Name  string
+			Audit Audit `bson:",inline"`
+		}
+
+		q, err := kyte.ProjectionFromStruct(&UserSummary{}).Build()
+		if err != nil {
+			t.Errorf("ProjectionFromStruct should not return error: %v", err)
+		}
+
+		target := bson.D{{Key: "name", Value: 1}, {Key: "created_at", Value: 1}}
+		if !reflect.DeepEqual(q, target) {
+			t.Errorf("ProjectionFromStruct should return value %v, got %v", target, q)
+		}
+	})
+
+	t.Run("from struct with field not in source", func(t *testing.T) {
+		type UserSummary struct {
+			Name    string `bson:"name"`
+			Surname string `bson:"surname"`
+		}
+
+		var user User
+		_, err := kyte.ProjectionFromStruct(UserSummary{}, kyte.Source(&user)).Build()
+		if !errors.Is(err, kyte.ErrNotValidFieldForQuery) {
+			t.Errorf("ProjectionFromStruct should return error %v, got %v", kyte.ErrNotValidFieldForQuery, err)
+		}
+
+		_, err = kyte.ProjectionFromStruct("summary").Build()
+		if err != kyte.ErrNotStruct {
+			t.Errorf("ProjectionFromStruct should return error %v, got %v", kyte.ErrNotStruct, err)
+		}
+	})
+
+	t.Run("mixed inclusion and exclusion", func(t *testing.T) {
+		_, err := kyte.Projection().Include("name").Exclude("password").Build()
+		if err != kyte.ErrProjectionMixed {
+			t.Errorf("Projection.Build should return error %v, got %v", kyte.ErrProjectionMixed, err)
+		}
+	})
+
+	t.Run("path collision", func(t *testing.T) {
+		_, err := kyte.Projection().Include("address", "address.city").Build()
+		if !errors.Is(err, kyte.ErrProjectionPathCollision) {
+			t.Errorf("Projection.Build should return error %v, got %v", kyte.ErrProjectionPathCollision, err)
+		}
+	})
+
+	t.Run("invalid field", func(t *testing.T) {
+		var user User
+		_, err := kyte.Projection(kyte.Source(&user)).Include("surname").Build()
+		if !errors.Is(err, kyte.ErrNotValidFieldForQuery) {
+			t.Errorf("Projection.Build should return error %v, got %v", kyte.ErrNotValidFieldForQuery, err)
+		}
+	})
+}
@@ -0,0 +1,284 @@
+package kyte
+
+import (
+	"fmt"
+	"strings"
+)
+
+// rsqlOperators are the comparison operators of RSQL/FIQL, the FIQL form =op= and the RSQL shorthands are both supported.
+var rsqlOperators = map[string]string{
+	"==":    eq,
+	"!=":    ne,
+	"=gt=":  gt,
+	">":     gt,
+	"=ge=":  gte,
+	">=":    gte,
+	"=lt=":  lt,
+	"<":     lt,
+	"=le=":  lte,
+	"<=":    lte,
+	"=in=":  in,
+	"=out=": nin,
+	"=re=":  regx,
+	"=ex=":  exists,
+}
+
+/*
+FromRSQL creates a filter from an [RSQL] query, the values are converted to the types of the source struct fields.
+The operators and the fields can be limited with [AllowOperators] and [AllowFields] so the query can be exposed to the end users.
+
+	kyte.FromRSQL("status==active;(age=gt=30,name=re=^J)", kyte.Source(&user))
+	// {"$or": [{"age": {"$gt": 30}}, {"name": {"$regex": "^J"}}], "status": {"$eq": "active"}}
+
+The logical operators are ; (and) and , (or), and has a higher precedence and the parentheses can be used to group the expressions.
+The comparison operators are == != =gt= > =ge= >= =lt= < =le= <= =in= =out= and the extensions =re= ($regex) and =ex= ($exists).
+The arguments of =in= and =out= are in parentheses such as status=in=(active,pending). The values that contain reserved characters
+or whitespace must be quoted with single or double quotes, a backslash escapes the next character.
+
+A syntax error is returned as [*SyntaxError] with the offset of the invalid token, the groups cannot be nested deeper than 64 levels.
+The selectors cannot have an empty segment or a segment beginning with $, such as $where.
+
+[RSQL]: https://github.com/jirutka/rsql-parser
+*/
+func FromRSQL(query string, opts ...OptionFunc) (*FilterBuilder, error) {
+	b, f, err := newExpressionBuilder(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSpace(query) == "" {
+		return f, nil
+	}
+
+	p := &rsqlParser{input: query}
+	expr, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := b.apply(f, expr); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+/*
+rsqlParser is a recursive descent parser of the RSQL grammar:
+
+	or         = and { "," and }
+	and        = constraint { ";" constraint }
+	constraint = "(" or ")" | selector comparator arguments
+	arguments  = "(" value { "," value } ")" | value
+*/
+type rsqlParser struct {
+	input string
+	pos   int
+	depth int
+}
+
+func (p *rsqlParser) parse() (expression, error) {
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	p.skipSpace()
+	if p.pos < len(p.input) {
+		return nil, p.errorf("unexpected %q", p.input[p.pos])
+	}
+	return expr, nil
+}
+
+func (p *rsqlParser) parseOr() (expression, error) {
+	return parseLogical(or, p.pos, p.parseAnd, func() bool { return p.consume(',') })
+}
+
+func (p *rsqlParser) parseAnd() (expression, error) {
+	return parseLogical(and, p.pos, p.parseConstraint, func() bool { return p.consume(';') })
+}
+
+func (p *rsqlParser) parseConstraint() (expression, error) {
+	p.skipSpace()
+	if p.consume('(') {
+		if p.depth == maxExpressionDepth {
+			return nil, nestingError(p.pos - 1)
+		}
+
+		p.depth++
+		expr, err := p.parseOr()
+		p.depth--
+		if err != nil {
+			return nil, err
+		}
+
+		if !p.consume(')') {
+			return nil, p.errorf("expected ')'")
+		}
+		return expr, nil
+	}
+
+	start := p.pos
+	field := p.unreserved()
+	if field == "" {
+		return nil, p.errorf("expected a selector")
+	}
+
+	p.skipSpace()
+	operatorPos := p.pos
+	comparator := p.comparator()
+	operator, ok := rsqlOperators[comparator]
+	if !ok {
+		if comparator == "" {
+			return nil, p.errorf("expected a comparison operator")
+		}
+		return nil, &SyntaxError{Offset: operatorPos, Msg: fmt.Sprintf("unsupported comparison operator %q", comparator)}
+	}
+
+	values, err := p.arguments(operator == in || operator == nin)
+	if err != nil {
+		return nil, err
+	}
+
+	return &comparisonExpr{pos: start, field: field, operator: operator, values: rawLiterals(values)}, nil
+}
+
+func (p *rsqlParser) comparator() string {
+	for _, c := range []string{"==", "!=", ">=", "<=", ">", "<"} {
+		if strings.HasPrefix(p.input[p.pos:], c) {
+			p.pos += len(c)
+			return c
+		}
+	}
+
+	if !strings.HasPrefix(p.input[p.pos:], "=") {
+		return ""
+	}
+
+	end := p.pos + 1
+	for end < len(p.input) && isASCIILetter(p.input[end]) {
+		end++
+	}
+	if end == p.pos+1 || end >= len(p.input) || p.input[end] != '=' {
+		return ""
+	}
+
+	comparator := p.input[p.pos : end+1]
+	p.pos = end + 1
+	return comparator
+}
+
+func (p *rsqlParser) arguments(multiple bool) ([]string, error) {
+	p.skipSpace()
+	if !p.consume('(') {
+		if multiple {
+			return nil, p.errorf("expected '(' before the list of values")
+		}
+
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		return []string{value}, nil
+	}
+
+	if !multiple {
+		return nil, &SyntaxError{Offset: p.pos - 1, Msg: "operator accepts a single value"}
+	}
+
+	var values []string
+	for {
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+
+		if p.consume(')') {
+			return values, nil
+		}
+		if !p.consume(',') {
+			return nil, p.errorf("expected ',' or ')'")
+		}
+	}
+}
+
+func (p *rsqlParser) value() (string, error) {
+	p.skipSpace()
+	if p.pos < len(p.input) && (p.input[p.pos] == '\'' || p.input[p.pos] == '"') {
+		return p.quoted()
+	}
+
+	value := p.unreserved()
+	if value == "" {
+		return "", p.errorf("expected a value")
+	}
+	return value, nil
+}
+
+func (p *rsqlParser) quoted() (string, error) {
+	start := p.pos
+	quote := p.input[p.pos]
+	p.pos++
+
+	var sb strings.Builder
+	for p.pos < len(p.input) {
+		c := p.input[p.pos]
+		switch {
+		case c == '\\' && p.pos+1 < len(p.input):
+			sb.WriteByte(p.input[p.pos+1])
+			p.pos += 2
+		case c == quote:
+			p.pos++
+			return sb.String(), nil
+		default:
+			sb.WriteByte(c)
+			p.pos++
+		}
+	}
+
+	return "", &SyntaxError{Offset: start, Msg: "unterminated quoted value"}
+}
+
+func (p *rsqlParser) unreserved() string {
+	start := p.pos
+	for p.pos < len(p.input) && !isRSQLReserved(p.input[p.pos]) {
+		p.pos++
+	}
+	return p.input[start:p.pos]
+}
+
+func (p *rsqlParser) consume(c byte) bool {
+	p.skipSpace()
+	if p.pos < len(p.input) && p.input[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *rsqlParser) skipSpace() {
+	for p.pos < len(p.input) && isSpace(p.input[p.pos]) {
+		p.pos++
+	}
+}
+
+func (p *rsqlParser) errorf(format string, args ...any) *SyntaxError {
+	msg := fmt.Sprintf(format, args...)
+	if p.pos >= len(p.input) {
+		msg += " at the end of the query"
+	}
+	return &SyntaxError{Offset: p.pos, Msg: msg}
+}
+
+func isRSQLReserved(c byte) bool {
+	return strings.IndexByte(`"'();,=!~<>`, c) >= 0 || isSpace(c)
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func isASCIILetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
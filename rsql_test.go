@@ -0,0 +1,146 @@
+package kyte_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aaydin-tr/kyte"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestFromRSQL(t *testing.T) {
+	t.Parallel()
+
+	var user TestUser
+	tests := []struct {
+		name   string
+		query  string
+		target bson.D
+	}{
+		{
+			name:  "and with nested or",
+			query: "status==active;(age=gt=30,name=re=^J)",
+			target: bson.D{
+				{Key: "$or", Value: bson.A{bson.M{"age": bson.M{"$gt": 30}}, bson.M{"name": bson.M{"$regex": "^J"}}}},
+				{Key: "status", Value: bson.M{"$eq": "active"}},
+			},
+		},
+		{
+			name:  "and has a higher precedence",
+			query: "age>=18;age<65,active==true",
+			target: bson.D{
+				{Key: "$or", Value: bson.A{
+					bson.M{"$and": bson.A{bson.M{"age": bson.M{"$gte": 18}}, bson.M{"age": bson.M{"$lt": 65}}}},
+					bson.M{"active": bson.M{"$eq": true}},
+				}},
+			},
+		},
+		{
+			name:  "lists and quoted values",
+			query: `status=out=(banned, "on hold");name!='O\'Brien';created_at=le=2024-01-02;tags=ex=true`,
+			target: bson.D{
+				{Key: "status", Value: bson.M{"$nin": []any{"banned", "on hold"}}},
+				{Key: "name", Value: bson.M{"$ne": "O'Brien"}},
+				{Key: "created_at", Value: bson.M{"$lte": time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)}},
+				{Key: "tags", Value: bson.M{"$exists": true}},
+			},
+		},
+		{
+			name:   "empty query",
+			query:  " ",
+			target: bson.D{},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := kyte.FromRSQL(tt.query, kyte.Source(&user))
+			testParsedFilter(t, "FromRSQL", f, err, tt.target)
+		})
+	}
+}
+
+func TestFromRSQL_Errors(t *testing.T) {
+	t.Parallel()
+
+	var user TestUser
+	t.Run("syntax errors", func(t *testing.T) {
+		tests := map[string]int{
+			"status==active;":      15,
+			"(age=gt=30":           10,
+			"age=gt=":              7,
+			"age=foo=1":            3,
+			"age 30":               4,
+			"status=in=active":     10,
+			"name=='open":          6,
+			"age==(1,2)":           5,
+			"status==active)":      14,
+			"status==a,age=in=(1,": 20,
+		}
+
+		for query, offset := range tests {
+			_, err := kyte.FromRSQL(query, kyte.Source(&user))
+
+			var syntaxErr *kyte.SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Errorf("FromRSQL should return SyntaxError for %q, got %v", query, err)
+				continue
+			}
+
+			if !errors.Is(err, kyte.ErrInvalidSyntax) {
+				t.Errorf("FromRSQL should return error %v, got %v", kyte.ErrInvalidSyntax, err)
+			}
+
+			if syntaxErr.Offset != offset {
+				t.Errorf("FromRSQL should return offset %d for %q, got %d", offset, query, syntaxErr.Offset)
+			}
+		}
+	})
+
+	t.Run("allowlist and values", func(t *testing.T) {
+		tests := map[string]error{
+			"name=re=^J":          kyte.ErrOperatorNotAllowed,
+			"score==1":            kyte.ErrFieldNotAllowed,
+			"password==secret":    kyte.ErrFieldNotAllowed,
+			"age==thirty":         kyte.ErrInvalidParamValue,
+			"status==a,name=re=x": kyte.ErrOperatorNotAllowed,
+		}
+
+		for query, target := range tests {
+			_, err := kyte.FromRSQL(query, kyte.Source(&user), kyte.AllowOperators("eq", "in"), kyte.AllowFields(&user.Age, &user.Status, &user.Name))
+			if !errors.Is(err, target) {
+				t.Errorf("FromRSQL should return error %v for %q, got %v", target, query, err)
+			}
+		}
+
+		_, err := kyte.FromRSQL("password==secret", kyte.Source(&user))
+		if !errors.Is(err, kyte.ErrNotValidFieldForQuery) {
+			t.Errorf("FromRSQL should return error %v, got %v", kyte.ErrNotValidFieldForQuery, err)
+		}
+	})
+
+	t.Run("untrusted input", func(t *testing.T) {
+		for _, query := range []string{"$where==x", "age==1,profile.$where==x", "profile..age==1"} {
+			f, err := kyte.FromRSQL(query)
+			if !errors.Is(err, kyte.ErrInvalidFieldPath) || f != nil {
+				t.Errorf("FromRSQL should return error %v for %q, got %v", kyte.ErrInvalidFieldPath, query, err)
+			}
+		}
+
+		depth := 100000
+		_, err := kyte.FromRSQL(strings.Repeat("(", depth) + "age==1" + strings.Repeat(")", depth))
+
+		var syntaxErr *kyte.SyntaxError
+		if !errors.As(err, &syntaxErr) || syntaxErr.Offset != 64 {
+			t.Errorf("FromRSQL should return SyntaxError at offset 64, got %v", err)
+		}
+
+		_, err = kyte.FromRSQL(strings.Repeat("(", 64) + "age==1" + strings.Repeat(")", 64))
+		if err != nil {
+			t.Errorf("FromRSQL should not return error: %v", err)
+		}
+	})
+}
@@ -0,0 +1,286 @@
+package kyte
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+/*
+Schema holds a model of the source struct, the addresses of the model fields are used to create typed field handles.
+
+	s := For[User]()
+	age := FieldOf(s, &s.Model.Age)   // Field[int]
+	tags := ArrayOf(s, &s.Model.Tags) // ArrayField[string]
+
+	s.Filter().
+		With(age.GreaterThan(18), tags.In([]string{"go", "mongo"}))
+*/
+type Schema[T any] struct {
+	// Model is the zero value of the source struct, only the addresses of its fields are used.
+	Model *T
+
+	kyte *kyte
+}
+
+/*
+Field is a typed handle of a source struct field, the conditions created from it only accept values of the field type
+so a value of a wrong type fails to compile. It can be used as a field in all builders.
+
+	age.GreaterThan(18)       // {"age": {"$gt": 18}}
+	age.GreaterThan("twenty") // does not compile
+	Sort().Desc(age)          // {"age": -1}
+*/
+type Field[V any] struct {
+	path string
+	err  error
+}
+
+/*
+ArrayField is a typed handle of a slice field, the conditions created from it accept the values of the slice element type.
+*/
+type ArrayField[E any] struct {
+	Field[[]E]
+}
+
+/*
+For creates a new schema of the given source struct.
+*/
+func For[T any]() *Schema[T] {
+	model := new(T)
+	return &Schema[T]{Model: model, kyte: newKyte(model, true)}
+}
+
+/*
+FieldOf returns a typed handle of the given field of the schema model, the field must be a pointer to a field of [Schema.Model].
+
+	FieldOf(s, &s.Model.Age) // Field[int]
+*/
+func FieldOf[T, V any](s *Schema[T], field *V) Field[V] {
+	path, err := s.fieldPath(field)
+	return Field[V]{path: path, err: err}
+}
+
+/*
+ArrayOf returns a typed handle of the given slice field of the schema model.
+
+	ArrayOf(s, &s.Model.Tags) // ArrayField[string]
+*/
+func ArrayOf[T, E any](s *Schema[T], field *[]E) ArrayField[E] {
+	path, err := s.fieldPath(field)
+	return ArrayField[E]{Field[[]E]{path: path, err: err}}
+}
+
+func (s *Schema[T]) fieldPath(field any) (string, error) {
+	if s.kyte.hasErrors() {
+		return "", s.kyte.err
+	}
+
+	path, ok := s.kyte.lookupField(field)
+	if !ok {
+		return "", errors.Join(ErrNotValidFieldForQuery, fmt.Errorf("field is not a field of the schema model"))
+	}
+	return path, nil
+}
+
+/*
+Filter creates a new filter with the schema model as the source.
+*/
+func (s *Schema[T]) Filter(opts ...OptionFunc) *FilterBuilder {
+	return Filter(append([]OptionFunc{Source(s.Model)}, opts...)...)
+}
+
+/*
+Sort creates a new sort with the schema model as the source.
+*/
+func (s *Schema[T]) Sort(opts ...OptionFunc) *SortBuilder {
+	return Sort(append([]OptionFunc{Source(s.Model)}, opts...)...)
+}
+
+/*
+Projection creates a new projection with the schema model as the source.
+*/
+func (s *Schema[T]) Projection(opts ...OptionFunc) *ProjectionBuilder {
+	return Projection(append([]OptionFunc{Source(s.Model)}, opts...)...)
+}
+
+/*
+Update creates a new update with the schema model as the source.
+*/
+func (s *Schema[T]) Update(opts ...OptionFunc) *UpdateBuilder {
+	return Update(append([]OptionFunc{Source(s.Model)}, opts...)...)
+}
+
+/*
+Pipeline creates a new pipeline with the schema model as the source.
+*/
+func (s *Schema[T]) Pipeline(opts ...OptionFunc) *PipelineBuilder {
+	return Pipeline(append([]OptionFunc{Source(s.Model)}, opts...)...)
+}
+
+/*
+Path returns the bson path of the field.
+*/
+func (f Field[V]) Path() string {
+	return f.path
+}
+
+func (f Field[V]) fieldPath(_ *kyte) (string, error) {
+	return f.path, f.err
+}
+
+func (f Field[V]) filter() *FilterBuilder {
+	return Filter(IgnoreGlobalFilters())
+}
+
+/*
+Equal use mongo [$eq] operator to compare the field and the value.
+
+[$eq]: https://www.mongodb.com/docs/manual/reference/operator/query/eq/#mongodb-query-op.-eq
+*/
+func (f Field[V]) Equal(value V) Condition {
+	return f.filter().Equal(f, value)
+}
+
+/*
+NotEqual use mongo [$ne] operator to compare the field and the value.
+
+[$ne]: https://www.mongodb.com/docs/manual/reference/operator/query/ne/#mongodb-query-op.-ne
+*/
+func (f Field[V]) NotEqual(value V) Condition {
+	return f.filter().NotEqual(f, value)
+}
+
+/*
+GreaterThan use mongo [$gt] operator to compare the field and the value.
+
+[$gt]: https://www.mongodb.com/docs/manual/reference/operator/query/gt/#mongodb-query-op.-gt
+*/
+func (f Field[V]) GreaterThan(value V) Condition {
+	return f.filter().GreaterThan(f, value)
+}
+
+/*
+GreaterThanOrEqual use mongo [$gte] operator to compare the field and the value.
+
+[$gte]: https://www.mongodb.com/docs/manual/reference/operator/query/gte/#mongodb-query-op.-gte
+*/
+func (f Field[V]) GreaterThanOrEqual(value V) Condition {
+	return f.filter().GreaterThanOrEqual(f, value)
+}
+
+/*
+LessThan use mongo [$lt] operator to compare the field and the value.
+
+[$lt]: https://www.mongodb.com/docs/manual/reference/operator/query/lt/#mongodb-query-op.-lt
+*/
+func (f Field[V]) LessThan(value V) Condition {
+	return f.filter().LessThan(f, value)
+}
+
+/*
+LessThanOrEqual use mongo [$lte] operator to compare the field and the value.
+
+[$lte]: https://www.mongodb.com/docs/manual/reference/operator/query/lte/#mongodb-query-op.-lte
+*/
+func (f Field[V]) LessThanOrEqual(value V) Condition {
+	return f.filter().LessThanOrEqual(f, value)
+}
+
+/*
+In use mongo [$in] operator to check if the field is equal to any of the values.
+
+[$in]: https://www.mongodb.com/docs/manual/reference/operator/query/in/#mongodb-query-op.-in
+*/
+func (f Field[V]) In(values []V) Condition {
+	return f.filter().In(f, nonNil(values))
+}
+
+/*
+NotIn use mongo [$nin] operator to check if the field is not equal to any of the values.
+
+[$nin]: https://www.mongodb.com/docs/manual/reference/operator/query/nin/#mongodb-query-op.-nin
+*/
+func (f Field[V]) NotIn(values []V) Condition {
+	return f.filter().NotIn(f, nonNil(values))
+}
+
+/*
+Exists use mongo [$exists] operator to check if the field exists.
+
+[$exists]: https://www.mongodb.com/docs/manual/reference/operator/query/exists/#mongodb-query-op.-exists
+*/
+func (f Field[V]) Exists(value bool) Condition {
+	return f.filter().Exists(f, value)
+}
+
+/*
+Regex use mongo [$regex] operator to match the field with the regular expression.
+
+[$regex]: https://www.mongodb.com/docs/manual/reference/operator/query/regex/#mongodb-query-op.-regex
+*/
+func (f Field[V]) Regex(regex *regexp.Regexp, options ...string) Condition {
+	return f.filter().Regex(f, regex, options...)
+}
+
+/*
+Contains matches the documents where the array contains the value.
+
+	tags.Contains("go") // {"tags": {"$eq": "go"}}
+*/
+func (f ArrayField[E]) Contains(value E) Condition {
+	return f.filter().Equal(f, value)
+}
+
+/*
+In use mongo [$in] operator to check if the array contains any of the values.
+
+	tags.In([]string{"go", "mongo"}) // {"tags": {"$in": ["go", "mongo"]}}
+*/
+func (f ArrayField[E]) In(values []E) Condition {
+	return f.filter().In(f, nonNil(values))
+}
+
+/*
+NotIn use mongo [$nin] operator to check if the array does not contain any of the values.
+*/
+func (f ArrayField[E]) NotIn(values []E) Condition {
+	return f.filter().NotIn(f, nonNil(values))
+}
+
+/*
+All use mongo [$all] operator to check if the array contains all the values.
+
+[$all]: https://www.mongodb.com/docs/manual/reference/operator/query/all/#mongodb-query-op.-all
+*/
+func (f ArrayField[E]) All(values []E) Condition {
+	return f.filter().All(f, nonNil(values))
+}
+
+/*
+Size use mongo [$size] operator to check the number of elements of the array.
+
+[$size]: https://www.mongodb.com/docs/manual/reference/operator/query/size/#mongodb-query-op.-size
+*/
+func (f ArrayField[E]) Size(size int) Condition {
+	return f.filter().Size(f, size)
+}
+
+/*
+ElemMatch use mongo [$elemMatch] operator to match the elements of the array with the filter.
+
+[$elemMatch]: https://www.mongodb.com/docs/manual/reference/operator/query/elemMatch/#mongodb-query-op.-elemMatch
+*/
+func (f ArrayField[E]) ElemMatch(filter *FilterBuilder) Condition {
+	return f.filter().ElemMatch(f, filter)
+}
+
+/*
+nonNil returns an empty slice instead of nil, so it is encoded as an empty array instead of null.
+*/
+func nonNil[V any](values []V) []V {
+	if values == nil {
+		return []V{}
+	}
+	return values
+}
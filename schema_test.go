@@ -0,0 +1,131 @@
+package kyte_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aaydin-tr/kyte"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestSchema(t *testing.T) {
+	t.Parallel()
+
+	type Address struct {
+		City string `bson:"city"`
+	}
+
+	type User struct {
+		ID      string   `bson:"_id"`
+		Name    string   `bson:"name"`
+		Age     int      `bson:"age"`
+		Tags    []string `bson:"tags"`
+		Address Address  `bson:"address"`
+	}
+
+	s := kyte.For[User]()
+	name := kyte.FieldOf(s, &s.Model.Name)
+	age := kyte.FieldOf(s, &s.Model.Age)
+	tags := kyte.ArrayOf(s, &s.Model.Tags)
+	city := kyte.FieldOf(s, &s.Model.Address.City)
+
+	t.Run("path", func(t *testing.T) {
+		if city.Path() != "address.city" {
+			t.Errorf("Field.Path should return address.city, got %s", city.Path())
+		}
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		q, err := s.Filter(kyte.IgnoreGlobalFilters()).
+			Equal(name, "John").
+			With(
+				age.GreaterThanOrEqual(18),
+				tags.In([]string{"go", "mongo"}),
+				city.NotIn(nil),
+			).
+			Or(kyte.Filter().With(tags.Size(2), tags.Contains("go"))).
+			Build()
+		if err != nil {
+			t.Errorf("Filter.Build should not return error: %v", err)
+		}
+
+		target := bson.D{
+			{Key: "age", Value: bson.M{"$gte": 18}},
+			{Key: "tags", Value: bson.M{"$in": []string{"go", "mongo"}}},
+			{Key: "address.city", Value: bson.M{"$nin": []string{}}},
+			{Key: "$or", Value: bson.A{
+				bson.M{"tags": bson.M{"$size": 2}},
+				bson.M{"tags": bson.M{"$eq": "go"}},
+			}},
+			{Key: "name", Value: bson.M{"$eq": "John"}},
+		}
+		if !reflect.DeepEqual(q, target) {
+			t.Errorf("Filter.Build should return value %v, got %v", target, q)
+		}
+	})
+
+	t.Run("other builders", func(t *testing.T) {
+		sortSpec, err := s.Sort().Desc(age).Asc(name).Build()
+		if err != nil {
+			t.Errorf("Sort.Build should not return error: %v", err)
+		}
+
+		targetSort := bson.D{{Key: "age", Value: -1}, {Key: "name", Value: 1}}
+		if !reflect.DeepEqual(sortSpec, targetSort) {
+			t.Errorf("Sort.Build should return value %v, got %v", targetSort, sortSpec)
+		}
+
+		projection, err := s.Projection().Include(name, city).Build()
+		if err != nil {
+			t.Errorf("Projection.Build should not return error: %v", err)
+		}
+
+		targetProjection := bson.D{{Key: "name", Value: 1}, {Key: "address.city", Value: 1}}
+		if !reflect.DeepEqual(projection, targetProjection) {
+			t.Errorf("Projection.Build should return value %v, got %v", targetProjection, projection)
+		}
+
+		update, err := s.Update().Set(name, "Doe").Inc(age, 1).Push(tags, "kyte").Build()
+		if err != nil {
+			t.Errorf("Update.Build should not return error: %v", err)
+		}
+
+		targetUpdate := bson.D{
+			{Key: "$set", Value: bson.D{{Key: "name", Value: "Doe"}}},
+			{Key: "$inc", Value: bson.D{{Key: "age", Value: 1}}},
+			{Key: "$push", Value: bson.D{{Key: "tags", Value: "kyte"}}},
+		}
+		if !reflect.DeepEqual(update, targetUpdate) {
+			t.Errorf("Update.Build should return value %v, got %v", targetUpdate, update)
+		}
+
+		p, err := s.Pipeline().Group(city, bson.D{{Key: "total", Value: bson.M{"$sum": 1}}}).Build()
+		if err != nil {
+			t.Errorf("Pipeline.Build should not return error: %v", err)
+		}
+
+		testPipelineJSON(t, p, `{"pipeline":[{"$group":{"_id":"$address.city","total":{"$sum":1}}}]}`)
+	})
+
+	t.Run("field of another struct", func(t *testing.T) {
+		var other User
+		field := kyte.FieldOf(s, &other.Name)
+		_, err := s.Filter().With(field.Equal("John")).Build()
+		if !errors.Is(err, kyte.ErrNotValidFieldForQuery) {
+			t.Errorf("Filter.Build should return error %v, got %v", kyte.ErrNotValidFieldForQuery, err)
+		}
+	})
+
+	t.Run("field is validated against the filter source", func(t *testing.T) {
+		type Product struct {
+			Title string `bson:"title"`
+		}
+
+		var product Product
+		_, err := kyte.Filter(kyte.Source(&product)).With(name.Equal("John")).Build()
+		if !errors.Is(err, kyte.ErrNotValidFieldForQuery) {
+			t.Errorf("Filter.Build should return error %v, got %v", kyte.ErrNotValidFieldForQuery, err)
+		}
+	})
+}
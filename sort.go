@@ -0,0 +1,113 @@
+package kyte
+
+import (
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	meta      = "$meta"
+	textScore = "textScore"
+)
+
+/*
+SortBuilder builds a mongo sort specification, it is created by [Sort].
+*/
+type SortBuilder struct {
+	kyte       *kyte
+	operations []operation
+}
+
+/*
+Sort creates a new sort specification builder that can be used with options.Find().SetSort. It uses the same options as [Filter], so the fields are validated against the source struct.
+The order of the fields is preserved and a field cannot be used more than once.
+
+	kyte.Sort(kyte.Source(&user)).
+		Desc(&user.CreatedAt).
+		Asc(&user.Name).
+		Build() // {"createdAt": -1, "name": 1}
+*/
+func Sort(opts ...OptionFunc) *SortBuilder {
+	options := newOptions(opts)
+	s := newSort(options)
+	s.kyte.setError(options.unsupported(0))
+	return s
+}
+
+func newSort(options *Options) *SortBuilder {
+	return &SortBuilder{
+		kyte: newKyteWithOptions(options),
+	}
+}
+
+/*
+Asc sorts the documents by the given field in ascending order.
+
+	Sort().
+		Asc("name") // {"name": 1}
+*/
+func (s *SortBuilder) Asc(field any) *SortBuilder {
+	return s.set(field, 1)
+}
+
+/*
+Desc sorts the documents by the given field in descending order.
+
+	Sort().
+		Desc("createdAt") // {"createdAt": -1}
+*/
+func (s *SortBuilder) Desc(field any) *SortBuilder {
+	return s.set(field, -1)
+}
+
+/*
+TextScore sorts the documents by the relevance score of a $text search, the score is assigned to the given field.
+
+	Sort().
+		TextScore("score") // {"score": {"$meta": "textScore"}}
+*/
+func (s *SortBuilder) TextScore(field any) *SortBuilder {
+	return s.set(field, bson.M{meta: textScore})
+}
+
+/*
+Build returns the sort specification as bson.D. If there is an error, it will return nil and the first error.
+*/
+func (s *SortBuilder) Build() (bson.D, error) {
+	if s.kyte.hasErrors() {
+		return nil, s.kyte.err
+	}
+
+	query := bson.D{}
+	for _, opt := range s.operations {
+		fieldName, err := s.kyte.resolveField(opt.field)
+		if err != nil {
+			s.kyte.setError(err)
+			return nil, err
+		}
+
+		for _, q := range query {
+			if q.Key == fieldName {
+				err := errors.Join(ErrDuplicateSortKey, fmt.Errorf("field: %s", fieldName))
+				s.kyte.setError(err)
+				return nil, err
+			}
+		}
+
+		query = append(query, bson.E{Key: fieldName, Value: opt.value})
+	}
+
+	return query, nil
+}
+
+func (s *SortBuilder) set(field any, value any) *SortBuilder {
+	s.operations = append(s.operations, operation{
+		field:           field,
+		value:           value,
+		isFieldRequired: true,
+	})
+
+	return s
+}
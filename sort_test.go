@@ -0,0 +1,75 @@
+package kyte_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aaydin-tr/kyte"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestSort_Build(t *testing.T) {
+	t.Parallel()
+
+	type User struct {
+		Name      string  `bson:"name"`
+		Age       int     `bson:"age"`
+		Score     float64 `bson:"score"`
+		CreatedAt string  `bson:"createdAt"`
+	}
+
+	t.Run("without source", func(t *testing.T) {
+		q, err := kyte.Sort().Desc("createdAt").Asc("name").Build()
+		if err != nil {
+			t.Errorf("Sort.Build should not return error: %v", err)
+		}
+
+		target := bson.D{{Key: "createdAt", Value: -1}, {Key: "name", Value: 1}}
+		if !reflect.DeepEqual(q, target) {
+			t.Errorf("Sort.Build should return value %v, got %v", target, q)
+		}
+	})
+
+	t.Run("with source", func(t *testing.T) {
+		var user User
+		q, err := kyte.Sort(kyte.Source(&user)).
+			TextScore(&user.Score).
+			Asc(&user.Age).
+			Desc("name").
+			Build()
+		if err != nil {
+			t.Errorf("Sort.Build should not return error: %v", err)
+		}
+
+		target := bson.D{
+			{Key: "score", Value: bson.M{"$meta": "textScore"}},
+			{Key: "age", Value: 1},
+			{Key: "name", Value: -1},
+		}
+		if !reflect.DeepEqual(q, target) {
+			t.Errorf("Sort.Build should return value %v, got %v", target, q)
+		}
+	})
+
+	t.Run("invalid field", func(t *testing.T) {
+		var user User
+		_, err := kyte.Sort(kyte.Source(&user)).Asc("surname").Build()
+		if !errors.Is(err, kyte.ErrNotValidFieldForQuery) {
+			t.Errorf("Sort.Build should return error %v, got %v", kyte.ErrNotValidFieldForQuery, err)
+		}
+
+		_, err = kyte.Sort().Asc(nil).Build()
+		if err != kyte.ErrNilField {
+			t.Errorf("Sort.Build should return error %v, got %v", kyte.ErrNilField, err)
+		}
+	})
+
+	t.Run("duplicate field", func(t *testing.T) {
+		var user User
+		_, err := kyte.Sort(kyte.Source(&user)).Asc(&user.Name).Desc("name").Build()
+		if !errors.Is(err, kyte.ErrDuplicateSortKey) {
+			t.Errorf("Sort.Build should return error %v, got %v", kyte.ErrDuplicateSortKey, err)
+		}
+	})
+}
@@ -0,0 +1,425 @@
+package kyte
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// sqlOperators are the comparison operators of SQL.
+var sqlOperators = map[string]string{
+	"=":  eq,
+	"!=": ne,
+	"<>": ne,
+	">":  gt,
+	">=": gte,
+	"<":  lt,
+	"<=": lte,
+}
+
+// sqlArithmetic are the operators of SQL that cannot be translated to a filter.
+var sqlArithmetic = []string{"+", "-", "*", "/", "%", "||"}
+
+/*
+FromSQLWhere creates a filter from the condition of a SQL WHERE clause, the columns are the bson paths of the fields such as
+address.city and the values are converted to the types of the source struct fields.
+
+	kyte.FromSQLWhere("age > 30 AND status IN ('a','b') AND name LIKE 'J%' AND deleted_at IS NULL", kyte.Source(&user))
+	// {"age": {"$gt": 30}, "status": {"$in": ["a", "b"]}, "name": {"$regex": "^J"}, "deleted_at": {"$eq": null}}
+
+The supported subset is the comparisons (= != <> < <= > >=), IN, NOT IN, LIKE, ILIKE, BETWEEN, IS NULL, IS NOT NULL, AND, OR, NOT
+and the parentheses. The keywords are case insensitive and the columns can be quoted with double quotes or backticks.
+The literals are strings in single quotes, numbers, TRUE, FALSE, NULL and DATE '2024-01-02' or TIMESTAMP '2024-01-02T10:00:00Z'.
+LIKE is translated to $regex, % matches any text and _ matches a single character, the newlines included.
+
+The operators and the fields can be limited with [AllowOperators] and [AllowFields], LIKE needs the regex operator and BETWEEN needs gte and lte.
+A syntax error is returned as [*SyntaxError] and a valid construct that cannot be translated such as the functions, the arithmetic
+operators and the comparison of two columns is returned as [*UnsupportedError]. The parentheses and NOT cannot be nested deeper than 64 levels
+and a column with an empty segment or a segment beginning with $, such as "$where", is rejected with [ErrInvalidFieldPath] even if it is quoted.
+*/
+func FromSQLWhere(where string, opts ...OptionFunc) (*FilterBuilder, error) {
+	b, f, err := newExpressionBuilder(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSpace(where) == "" {
+		return f, nil
+	}
+
+	p := &sqlParser{input: where}
+	p.foldCase = true
+	if err := p.tokenize(); err != nil {
+		return nil, err
+	}
+
+	expr, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := b.apply(f, expr); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+/*
+sqlParser is a recursive descent parser of the SQL WHERE conditions:
+
+	or        = and { "OR" and }
+	and       = not { "AND" not }
+	not       = "NOT" not | primary
+	primary   = "(" or ")" | column predicate
+	predicate = comparator value | [ "NOT" ] "IN" "(" value { "," value } ")" | [ "NOT" ] ( "LIKE" | "ILIKE" ) string
+	          | [ "NOT" ] "BETWEEN" value "AND" value | "IS" [ "NOT" ] "NULL"
+*/
+type sqlParser struct {
+	tokenStream
+	input string
+}
+
+func (p *sqlParser) tokenize() error {
+	i := 0
+	for i < len(p.input) {
+		c := p.input[i]
+		switch {
+		case isSpace(c):
+			i++
+		case c == '(':
+			p.add(tokenOpen, "(", i)
+			i++
+		case c == ')':
+			p.add(tokenClose, ")", i)
+			i++
+		case c == ',':
+			p.add(tokenComma, ",", i)
+			i++
+		case c == '\'', c == '"', c == '`':
+			text, end, ok := scanQuoted(p.input, i)
+			if !ok {
+				return &SyntaxError{Offset: i, Msg: "unterminated quoted text"}
+			}
+
+			kind := tokenString
+			if c != '\'' {
+				kind = tokenIdent
+			}
+			p.add(kind, text, i)
+			i = end
+		case isDigit(c):
+			start := i
+			for i++; i < len(p.input) && (isDigit(p.input[i]) || isASCIILetter(p.input[i]) || p.input[i] == '.' ||
+				((p.input[i] == '+' || p.input[i] == '-') && (p.input[i-1] == 'e' || p.input[i-1] == 'E'))); i++ {
+			}
+			p.add(tokenNumber, p.input[start:i], start)
+		case isASCIILetter(c) || c == '_':
+			start := i
+			for i++; i < len(p.input) && (isASCIILetter(p.input[i]) || isDigit(p.input[i]) || p.input[i] == '_' || p.input[i] == '.'); i++ {
+			}
+			p.add(tokenIdent, p.input[start:i], start)
+		case strings.IndexByte("=!<>+-*/%|", c) >= 0:
+			start := i
+			for _, op := range []string{"!=", "<>", "<=", ">=", "||"} {
+				if strings.HasPrefix(p.input[i:], op) {
+					i += len(op)
+					break
+				}
+			}
+			if i == start {
+				i++
+			}
+			p.add(tokenOperator, p.input[start:i], start)
+		default:
+			// the unknown characters are reported by the parser, so the unsupported constructs before them are reported first
+			p.add(tokenOther, string(c), i)
+			i++
+		}
+	}
+
+	p.add(tokenEOF, "", len(p.input))
+	return nil
+}
+
+func (p *sqlParser) parse() (expression, error) {
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, unexpectedError(t)
+	}
+	return expr, nil
+}
+
+func (p *sqlParser) parseOr() (expression, error) {
+	return parseLogical(or, p.peek().pos, p.parseAnd, func() bool { return p.keyword("or") })
+}
+
+func (p *sqlParser) parseAnd() (expression, error) {
+	return parseLogical(and, p.peek().pos, p.parseNot, func() bool { return p.keyword("and") })
+}
+
+func (p *sqlParser) parseNot() (expression, error) {
+	if t := p.peek(); p.keyword("not") {
+		if err := p.enter(t); err != nil {
+			return nil, err
+		}
+
+		expr, err := p.parseNot()
+		p.leave()
+		if err != nil {
+			return nil, err
+		}
+		return negateExpr(expr), nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *sqlParser) parsePrimary() (expression, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenOpen:
+		if err := p.enter(t); err != nil {
+			return nil, err
+		}
+
+		expr, err := p.parseOr()
+		p.leave()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := p.expect(tokenClose, "')'"); err != nil {
+			return nil, err
+		}
+		return expr, nil
+	case tokenString, tokenNumber:
+		return nil, &UnsupportedError{Offset: t.pos, Construct: "literal on the left side of the comparison"}
+	case tokenIdent:
+	default:
+		return nil, unexpectedError(t)
+	}
+
+	if p.peek().kind == tokenOpen {
+		return nil, &UnsupportedError{Offset: t.pos, Construct: fmt.Sprintf("function %s", t.text)}
+	}
+
+	for _, word := range []string{"true", "false", "null", "date", "timestamp"} {
+		if p.isKeyword(t, word) {
+			return nil, &UnsupportedError{Offset: t.pos, Construct: "literal on the left side of the comparison"}
+		}
+	}
+
+	return p.parsePredicate(t)
+}
+
+func (p *sqlParser) parsePredicate(column token) (expression, error) {
+	op := p.peek()
+	if op.kind == tokenOperator {
+		p.next()
+		operator, ok := sqlOperators[op.text]
+		if !ok {
+			return nil, &UnsupportedError{Offset: op.pos, Construct: fmt.Sprintf("operator %s", op.text)}
+		}
+
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+
+		if value.value == nil {
+			return nil, &UnsupportedError{Offset: op.pos, Construct: "comparison with NULL, use IS NULL or IS NOT NULL"}
+		}
+
+		if next := p.peek(); next.kind == tokenOperator && contains(sqlArithmetic, next.text) {
+			return nil, &UnsupportedError{Offset: next.pos, Construct: fmt.Sprintf("operator %s", next.text)}
+		}
+
+		return &comparisonExpr{pos: column.pos, field: column.text, operator: operator, values: []literal{value}}, nil
+	}
+
+	if p.keyword("is") {
+		operator := eq
+		if p.keyword("not") {
+			operator = ne
+		}
+
+		if !p.keyword("null") {
+			return nil, expectedError(p.peek(), "NULL")
+		}
+		return &comparisonExpr{pos: column.pos, field: column.text, operator: operator, values: []literal{{raw: "NULL"}}}, nil
+	}
+
+	negate := p.keyword("not")
+	if p.keyword("in") {
+		operator := in
+		if negate {
+			operator = nin
+		}
+		return p.parseIn(column, operator)
+	}
+
+	var expr expression
+	var err error
+	switch {
+	case p.keyword("like"):
+		expr, err = p.parseLike(column, false)
+	case p.keyword("ilike"):
+		expr, err = p.parseLike(column, true)
+	case p.keyword("between"):
+		expr, err = p.parseBetween(column)
+	default:
+		return nil, expectedError(p.peek(), "a comparison operator")
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if negate {
+		return negateExpr(expr), nil
+	}
+	return expr, nil
+}
+
+func (p *sqlParser) parseIn(column token, operator string) (expression, error) {
+	if err := p.expect(tokenOpen, "'('"); err != nil {
+		return nil, err
+	}
+
+	if t := p.peek(); p.isKeyword(t, "select") {
+		return nil, &UnsupportedError{Offset: t.pos, Construct: "subquery"}
+	}
+
+	var values []literal
+	for {
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+
+		t := p.next()
+		if t.kind == tokenClose {
+			return &comparisonExpr{pos: column.pos, field: column.text, operator: operator, values: values}, nil
+		}
+		if t.kind != tokenComma {
+			return nil, expectedError(t, "',' or ')'")
+		}
+	}
+}
+
+func (p *sqlParser) parseLike(column token, insensitive bool) (expression, error) {
+	t := p.next()
+	if t.kind != tokenString {
+		return nil, expectedError(t, "a string pattern")
+	}
+
+	if escape := p.peek(); p.isKeyword(escape, "escape") {
+		return nil, &UnsupportedError{Offset: escape.pos, Construct: "LIKE with ESCAPE"}
+	}
+
+	pattern := likePattern(t.text)
+	return &comparisonExpr{pos: column.pos, field: column.text, operator: regx, values: []literal{{raw: pattern, value: pattern}}, options: caseOptions(insensitive)}, nil
+}
+
+func (p *sqlParser) parseBetween(column token) (expression, error) {
+	low, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+
+	if !p.keyword("and") {
+		return nil, expectedError(p.peek(), "AND")
+	}
+
+	high, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+
+	return &logicalExpr{pos: column.pos, operator: and, operands: []expression{
+		&comparisonExpr{pos: column.pos, field: column.text, operator: gte, values: []literal{low}},
+		&comparisonExpr{pos: column.pos, field: column.text, operator: lte, values: []literal{high}},
+	}}, nil
+}
+
+func (p *sqlParser) parseValue() (literal, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenString:
+		return literal{raw: t.text, value: t.text}, nil
+	case tokenNumber:
+		return parseNumber(t, t.text)
+	case tokenOperator:
+		if next := p.peek(); t.text == "-" && next.kind == tokenNumber && next.pos == t.pos+1 {
+			p.next()
+			return parseNumber(t, "-"+next.text)
+		}
+	case tokenIdent:
+		switch {
+		case p.isKeyword(t, "true"), p.isKeyword(t, "false"):
+			return literal{raw: t.text, value: p.isKeyword(t, "true")}, nil
+		case p.isKeyword(t, "null"):
+			return literal{raw: t.text}, nil
+		case p.isKeyword(t, "date"), p.isKeyword(t, "timestamp"):
+			value := p.next()
+			if value.kind != tokenString {
+				return literal{}, expectedError(value, "a quoted date")
+			}
+
+			date, err := parseTime(value.text)
+			if err != nil {
+				return literal{}, &SyntaxError{Offset: value.pos, Msg: err.Error()}
+			}
+			return literal{raw: value.text, value: date}, nil
+		}
+
+		if p.peek().kind == tokenOpen {
+			return literal{}, &UnsupportedError{Offset: t.pos, Construct: fmt.Sprintf("function %s", t.text)}
+		}
+		return literal{}, &UnsupportedError{Offset: t.pos, Construct: "comparison of two columns"}
+	}
+
+	return literal{}, expectedError(t, "a value")
+}
+
+func parseNumber(t token, text string) (literal, error) {
+	if i, err := strconv.Atoi(text); err == nil {
+		return literal{raw: text, value: i}, nil
+	}
+	if f, err := strconv.ParseFloat(text, 64); err == nil {
+		return literal{raw: text, value: f}, nil
+	}
+	return literal{}, &SyntaxError{Offset: t.pos, Msg: fmt.Sprintf("invalid number %q", text)}
+}
+
+/*
+likePattern converts the LIKE pattern to a regular expression, the leading and the trailing wildcards are not anchored.
+The wildcards are [\s\S] instead of the dot since they also match the newlines like in SQL.
+*/
+func likePattern(like string) string {
+	var sb strings.Builder
+	sb.WriteString("^")
+	for _, r := range like {
+		switch r {
+		case '%':
+			sb.WriteString(`[\s\S]*`)
+		case '_':
+			sb.WriteString(`[\s\S]`)
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	sb.WriteString("$")
+
+	pattern := strings.TrimSuffix(sb.String(), `[\s\S]*$`)
+	if trimmed, ok := strings.CutPrefix(pattern, `^[\s\S]*`); ok {
+		pattern = trimmed
+	}
+	return pattern
+}
@@ -0,0 +1,202 @@
+package kyte_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aaydin-tr/kyte"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestFromSQLWhere(t *testing.T) {
+	t.Parallel()
+
+	var user TestUser
+	tests := []struct {
+		name   string
+		where  string
+		target bson.D
+	}{
+		{
+			name:  "subset of the request",
+			where: "age > 30 AND status IN ('a','b') AND name LIKE 'J%' AND deleted_at IS NULL",
+			target: bson.D{
+				{Key: "age", Value: bson.M{"$gt": 30}},
+				{Key: "status", Value: bson.M{"$in": []any{"a", "b"}}},
+				{Key: "name", Value: bson.M{"$regex": "^J"}},
+				{Key: "deleted_at", Value: bson.M{"$eq": nil}},
+			},
+		},
+		{
+			name:  "between, not in and is not null",
+			where: `score between 1000.5 and 2000 and "status" not in ('x') and deleted_at is not null and active = TRUE`,
+			target: bson.D{
+				{Key: "score", Value: bson.M{"$gte": 1000.5}},
+				{Key: "score", Value: bson.M{"$lte": float64(2000)}},
+				{Key: "status", Value: bson.M{"$nin": []any{"x"}}},
+				{Key: "deleted_at", Value: bson.M{"$ne": nil}},
+				{Key: "active", Value: bson.M{"$eq": true}},
+			},
+		},
+		{
+			name:  "or, not and parentheses",
+			where: "(age <= 18 OR age >= 65) AND NOT address.city ILIKE '%mir' AND created_at < DATE '2024-01-02' AND name <> 'O''Brien'",
+			target: bson.D{
+				{Key: "$or", Value: bson.A{bson.M{"age": bson.M{"$lte": 18}}, bson.M{"age": bson.M{"$gte": 65}}}},
+				{Key: "address.city", Value: bson.M{"$not": bson.M{"$regex": "mir$", "$options": "i"}}},
+				{Key: "created_at", Value: bson.M{"$lt": time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)}},
+				{Key: "name", Value: bson.M{"$ne": "O'Brien"}},
+			},
+		},
+		{
+			name:  "not between and like wildcards",
+			where: "NOT age BETWEEN 20 AND 30 AND name LIKE '_o%n.%' AND age != -1",
+			target: bson.D{
+				{Key: "$nor", Value: bson.A{
+					bson.M{"$and": bson.A{bson.M{"age": bson.M{"$gte": 20}}, bson.M{"age": bson.M{"$lte": 30}}}},
+				}},
+				{Key: "name", Value: bson.M{"$regex": `^[\s\S]o[\s\S]*n\.`}},
+				{Key: "age", Value: bson.M{"$ne": -1}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := kyte.FromSQLWhere(tt.where, kyte.Source(&user))
+			testParsedFilter(t, "FromSQLWhere", f, err, tt.target)
+		})
+	}
+}
+
+func TestFromSQLWhere_MultilineLike(t *testing.T) {
+	t.Parallel()
+
+	var user TestUser
+	for where, matches := range map[string]bool{
+		"name LIKE 'J%n'":   true,
+		"name LIKE 'John_'": false,
+		"name LIKE 'Jo_hn'": true,
+		"name LIKE 'John'":  false,
+	} {
+		f, err := kyte.FromSQLWhere(where, kyte.Source(&user))
+		if err != nil {
+			t.Fatalf("FromSQLWhere should not return error: %v", err)
+		}
+
+		q, err := f.Build()
+		if err != nil {
+			t.Fatalf("Filter.Build should not return error: %v", err)
+		}
+
+		matched, err := kyte.Match(q, bson.M{"name": "Jo\nhn"})
+		if err != nil {
+			t.Errorf("Match should not return error: %v", err)
+		}
+
+		if matched != matches {
+			t.Errorf("%s should match the multi-line value: %v, got %v", where, matches, matched)
+		}
+	}
+}
+
+func TestFromSQLWhere_Errors(t *testing.T) {
+	t.Parallel()
+
+	var user TestUser
+	t.Run("syntax errors", func(t *testing.T) {
+		tests := map[string]int{
+			"age >":                         5,
+			"(age > 30":                     9,
+			"name = 'John":                  7,
+			"age > 30 name = 'x'":           9,
+			"age 30":                        4,
+			"status IN 'a'":                 10,
+			"age BETWEEN 1 OR 2":            14,
+			"deleted_at IS NOT 1":           18,
+			"age > 30;":                     8,
+			"created_at > DATE 'yesterday'": 18,
+			"name LIKE 1":                   10,
+			strings.Repeat("(", 100) + "age > 1" + strings.Repeat(")", 100): 64,
+			strings.Repeat("NOT ", 100) + "age > 1":                         256,
+		}
+
+		for where, offset := range tests {
+			_, err := kyte.FromSQLWhere(where, kyte.Source(&user))
+
+			var syntaxErr *kyte.SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Errorf("FromSQLWhere should return SyntaxError for %q, got %v", where, err)
+				continue
+			}
+
+			if syntaxErr.Offset != offset {
+				t.Errorf("FromSQLWhere should return offset %d for %q, got %d", offset, where, syntaxErr.Offset)
+			}
+		}
+	})
+
+	t.Run("unsupported constructs", func(t *testing.T) {
+		tests := map[string]string{
+			"lower(name) = 'john'":             "function lower",
+			"age + 1 > 30":                     "operator +",
+			"age > 30 - 1":                     "operator -",
+			"score > age":                      "comparison of two columns",
+			"30 < age":                         "literal on the left side of the comparison",
+			"deleted_at = NULL":                "comparison with NULL, use IS NULL or IS NOT NULL",
+			"name LIKE 'J!%' ESCAPE '!'":       "LIKE with ESCAPE",
+			"status IN (SELECT status FROM t)": "subquery",
+			"created_at > now()":               "function now",
+		}
+
+		for where, construct := range tests {
+			_, err := kyte.FromSQLWhere(where, kyte.Source(&user))
+
+			var unsupportedErr *kyte.UnsupportedError
+			if !errors.As(err, &unsupportedErr) || !errors.Is(err, kyte.ErrUnsupportedExpression) {
+				t.Errorf("FromSQLWhere should return UnsupportedError for %q, got %v", where, err)
+				continue
+			}
+
+			if unsupportedErr.Construct != construct {
+				t.Errorf("FromSQLWhere should return construct %q for %q, got %q", construct, where, unsupportedErr.Construct)
+			}
+		}
+	})
+
+	t.Run("fields and operators", func(t *testing.T) {
+		tests := map[string]error{
+			"password = 'secret'":        kyte.ErrFieldNotAllowed,
+			"age = 'thirty'":             kyte.ErrInvalidParamValue,
+			"age BETWEEN 1 AND 2":        kyte.ErrOperatorNotAllowed,
+			"status IN ('a') OR age = 1": nil,
+		}
+
+		for where, target := range tests {
+			_, err := kyte.FromSQLWhere(where, kyte.Source(&user), kyte.AllowOperators("eq", "in"), kyte.AllowFields("age", "status"))
+			if target == nil && err != nil {
+				t.Errorf("FromSQLWhere should not return error for %q: %v", where, err)
+			}
+			if target != nil && !errors.Is(err, target) {
+				t.Errorf("FromSQLWhere should return error %v for %q, got %v", target, where, err)
+			}
+		}
+
+		_, err := kyte.FromSQLWhere("password = 'secret'", kyte.Source(&user))
+		if !errors.Is(err, kyte.ErrNotValidFieldForQuery) {
+			t.Errorf("FromSQLWhere should return error %v, got %v", kyte.ErrNotValidFieldForQuery, err)
+		}
+	})
+
+	t.Run("quoted operator identifiers", func(t *testing.T) {
+		for _, where := range []string{`"$where" = 'x'`, "`$expr` = 1", `age = 1 OR "address.$where" = 'x'`, `"address..city" = 'x'`} {
+			f, err := kyte.FromSQLWhere(where)
+			if !errors.Is(err, kyte.ErrInvalidFieldPath) || f != nil {
+				t.Errorf("FromSQLWhere should return error %v for %q, got %v", kyte.ErrInvalidFieldPath, where, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,119 @@
+package kyte
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	dateTimeType = reflect.TypeOf(primitive.DateTime(0))
+	decimalType  = reflect.TypeOf(primitive.Decimal128{})
+	regexType    = reflect.TypeOf(primitive.Regex{})
+	documentType = reflect.TypeOf(bson.D{})
+	rawType      = reflect.TypeOf(bson.Raw{})
+)
+
+/*
+checkValueType checks the value of the operator against the type of the source struct field, the fields that are not in the source are not checked.
+
+	$eq, $ne, $gt, $gte, $lt, $lte: the value must be of the field type, or of the element type for slices
+	$in, $nin, $all: the values must be of the field type, or of the element type for slices
+
+The operands of the other operators such as $size and $regex are already typed by their builder methods.
+*/
+func (k *kyte) checkValueType(path string, operator string, value any) error {
+	if !k.checkField {
+		return nil
+	}
+
+	expected, ok := k.fieldType(path)
+	if !ok {
+		return nil
+	}
+
+	switch operator {
+	case eq, ne, gt, gte, lt, lte:
+		if value != nil && !isCompatibleType(expected, reflect.TypeOf(value)) {
+			return valueTypeMismatch(path, expected, reflect.TypeOf(value))
+		}
+	case in, nin, all:
+		items, ok := toArray(value)
+		if !ok {
+			items = []any{value}
+		}
+
+		for _, item := range items {
+			if item != nil && !isCompatibleType(expected, reflect.TypeOf(item)) {
+				return valueTypeMismatch(path, expected, reflect.TypeOf(item))
+			}
+		}
+	}
+
+	return nil
+}
+
+func valueTypeMismatch(path string, expected reflect.Type, given reflect.Type) error {
+	return errors.Join(ErrValueTypeMismatch, fmt.Errorf("field: %s expected: %s given: %s", path, expected, given))
+}
+
+/*
+isCompatibleType reports whether a value of the given type can match a field of the expected type in mongo.
+Numbers are compatible with each other, and a slice field is compatible with its element type.
+*/
+func isCompatibleType(expected reflect.Type, given reflect.Type) bool {
+	expected, given = indirectType(expected), indirectType(given)
+	if expected.Kind() == reflect.Interface || given.Kind() == reflect.Interface || given.AssignableTo(expected) {
+		return true
+	}
+
+	switch {
+	case isNumberType(expected):
+		return isNumberType(given)
+	case isTimeType(expected):
+		return isTimeType(given)
+	case expected.Kind() == reflect.String:
+		return given.Kind() == reflect.String || given == regexType
+	case expected.Kind() == reflect.Bool:
+		return given.Kind() == reflect.Bool
+	case expected.Kind() == reflect.Struct, expected.Kind() == reflect.Map:
+		return given.Kind() == reflect.Map || given == documentType || given == rawType
+	case expected.Kind() == reflect.Slice, expected.Kind() == reflect.Array:
+		if given.Kind() == reflect.Slice || given.Kind() == reflect.Array {
+			return isCompatibleType(expected.Elem(), given.Elem())
+		}
+		return isCompatibleType(expected.Elem(), given)
+	}
+
+	return false
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+func isNumberType(t reflect.Type) bool {
+	if t == decimalType {
+		return true
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return t != dateTimeType
+	}
+	return false
+}
+
+func isTimeType(t reflect.Type) bool {
+	return t == timeType || t == dateTimeType
+}
@@ -0,0 +1,93 @@
+package kyte_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aaydin-tr/kyte"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestFilter_ValueType(t *testing.T) {
+	t.Parallel()
+
+	type Status string
+
+	type Order struct {
+		Total float64 `bson:"total"`
+	}
+
+	type User struct {
+		ID        primitive.ObjectID `bson:"_id"`
+		Name      string             `bson:"name"`
+		Age       int                `bson:"age"`
+		Status    Status             `bson:"status"`
+		Tags      []string           `bson:"tags"`
+		Orders    []Order            `bson:"orders"`
+		CreatedAt time.Time          `bson:"createdAt"`
+		Extra     any                `bson:"extra"`
+	}
+
+	t.Run("compatible values", func(t *testing.T) {
+		var user User
+		_, err := kyte.Filter(kyte.Source(&user)).
+			Equal(&user.ID, primitive.NewObjectID()).
+			Equal(&user.Name, "John").
+			GreaterThan(&user.Age, int64(18)).
+			LessThan(&user.Age, 65.5).
+			Equal(&user.Status, "active").
+			Equal(&user.Tags, "go").
+			Equal(&user.Tags, []string{"go", "mongo"}).
+			In(&user.Tags, []string{"go"}).
+			All(&user.Tags, []string{"go"}).
+			GreaterThan("orders.total", 100).
+			GreaterThan(&user.CreatedAt, primitive.NewDateTimeFromTime(time.Now())).
+			Equal(&user.Extra, true).
+			Equal(&user.Name, nil).
+			Build()
+		if err != nil {
+			t.Errorf("Filter.Build should not return error: %v", err)
+		}
+	})
+
+	t.Run("mismatched values", func(t *testing.T) {
+		var user User
+		tests := []struct {
+			filter  *kyte.FilterBuilder
+			message string
+		}{
+			{kyte.Filter(kyte.Source(&user)).Equal(&user.Name, 18), "field: name expected: string given: int"},
+			{kyte.Filter(kyte.Source(&user)).Equal(&user.Age, "18"), "field: age expected: int given: string"},
+			{kyte.Filter(kyte.Source(&user)).Not().GreaterThan(&user.Age, "18"), "field: age expected: int given: string"},
+			{kyte.Filter(kyte.Source(&user)).NotIn(&user.Age, "18"), "field: age expected: int given: string"},
+			{kyte.Filter(kyte.Source(&user)).Equal(&user.ID, "5f1d7f"), "field: _id expected: primitive.ObjectID given: string"},
+			{kyte.Filter(kyte.Source(&user)).In(&user.Tags, []int{1, 2}), "field: tags expected: []string given: int"},
+			{kyte.Filter(kyte.Source(&user)).All(&user.Tags, []any{"go", true}), "field: tags expected: []string given: bool"},
+			{kyte.Filter(kyte.Source(&user)).Equal("orders.total", "100"), "field: orders.total expected: float64 given: string"},
+			{kyte.Filter(kyte.Source(&user)).GreaterThan(&user.CreatedAt, "2024-01-01"), "field: createdAt expected: time.Time given: string"},
+			{kyte.Filter(kyte.Source(&user)).ElemMatch(&user.Orders, kyte.Filter().Equal("total", "100")), "field: total expected: float64 given: string"},
+		}
+
+		for _, tt := range tests {
+			_, err := tt.filter.Build()
+			if !errors.Is(err, kyte.ErrValueTypeMismatch) {
+				t.Errorf("Filter.Build should return error %v, got %v", kyte.ErrValueTypeMismatch, err)
+				continue
+			}
+
+			if !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("Filter.Build should return error with %q, got %v", tt.message, err)
+			}
+		}
+	})
+
+	t.Run("without validation", func(t *testing.T) {
+		var user User
+		_, err := kyte.Filter(kyte.Source(&user), kyte.ValidateField(false)).Equal(&user.Age, "18").Build()
+		if err != nil {
+			t.Errorf("Filter.Build should not return error: %v", err)
+		}
+	})
+}
@@ -0,0 +1,630 @@
+package kyte
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	set         = "$set"
+	unset       = "$unset"
+	inc         = "$inc"
+	mul         = "$mul"
+	_min        = "$min"
+	_max        = "$max"
+	rename      = "$rename"
+	currentDate = "$currentDate"
+	setOnInsert = "$setOnInsert"
+	push        = "$push"
+	addToSet    = "$addToSet"
+	pull        = "$pull"
+	pullAll     = "$pullAll"
+	pop         = "$pop"
+
+	each     = "$each"
+	slice    = "$slice"
+	position = "$position"
+)
+
+type pushOptions struct {
+	position *int
+	slice    *int
+	sort     any
+}
+
+type PushOptionFunc func(*pushOptions)
+
+/*
+PushPosition sets the [$position] modifier of PushEach to insert the elements at the given index.
+
+[$position]: https://www.mongodb.com/docs/manual/reference/operator/update/position/
+*/
+func PushPosition(position int) PushOptionFunc {
+	return func(o *pushOptions) {
+		o.position = &position
+	}
+}
+
+/*
+PushSlice sets the [$slice] modifier of PushEach to limit the number of array elements.
+
+[$slice]: https://www.mongodb.com/docs/manual/reference/operator/update/slice/
+*/
+func PushSlice(slice int) PushOptionFunc {
+	return func(o *pushOptions) {
+		o.slice = &slice
+	}
+}
+
+/*
+PushSort sets the [$sort] modifier of PushEach to order the array elements, it can be 1, -1 or a sort document for embedded documents.
+
+[$sort]: https://www.mongodb.com/docs/manual/reference/operator/update/sort/
+*/
+func PushSort(sort any) PushOptionFunc {
+	return func(o *pushOptions) {
+		o.sort = sort
+	}
+}
+
+/*
+UpdateBuilder builds a mongo update document, it is created by [Update].
+*/
+type UpdateBuilder struct {
+	kyte         *kyte
+	operations   []operation
+	arrayFilters []arrayFilter
+}
+
+type arrayFilter struct {
+	identifier string
+	filter     *FilterBuilder
+}
+
+/*
+Update creates a new update document builder. It uses the same options as [Filter], so the fields are validated against the source struct.
+
+	kyte.Update(kyte.Source(&user)).
+		Set(&user.Name, "John").
+		Inc(&user.Age, 1).
+		Build() // {"$set": {"name": "John"}, "$inc": {"age": 1}}
+*/
+func Update(opts ...OptionFunc) *UpdateBuilder {
+	options := newOptions(opts)
+	b := &UpdateBuilder{
+		kyte: newKyteWithOptions(options),
+	}
+	b.kyte.setError(options.unsupported(0))
+	return b
+}
+
+/*
+Set use mongo [$set] operator to replace the value of the field.
+
+	Update().
+		Set("name", "John") // {"$set": {"name": "John"}}
+
+[$set]: https://www.mongodb.com/docs/manual/reference/operator/update/set/
+*/
+func (u *UpdateBuilder) Set(field any, value any) *UpdateBuilder {
+	return u.set(set, field, value)
+}
+
+/*
+Unset use mongo [$unset] operator to delete the field.
+
+	Update().
+		Unset("name") // {"$unset": {"name": ""}}
+
+[$unset]: https://www.mongodb.com/docs/manual/reference/operator/update/unset/
+*/
+func (u *UpdateBuilder) Unset(field any) *UpdateBuilder {
+	return u.set(unset, field, "")
+}
+
+/*
+Inc use mongo [$inc] operator to increment the field by the given value.
+
+	Update().
+		Inc("age", 1) // {"$inc": {"age": 1}}
+
+[$inc]: https://www.mongodb.com/docs/manual/reference/operator/update/inc/
+*/
+func (u *UpdateBuilder) Inc(field any, value any) *UpdateBuilder {
+	return u.set(inc, field, value)
+}
+
+/*
+Mul use mongo [$mul] operator to multiply the field by the given value.
+
+	Update().
+		Mul("price", 1.25) // {"$mul": {"price": 1.25}}
+
+[$mul]: https://www.mongodb.com/docs/manual/reference/operator/update/mul/
+*/
+func (u *UpdateBuilder) Mul(field any, value any) *UpdateBuilder {
+	return u.set(mul, field, value)
+}
+
+/*
+Min use mongo [$min] operator to update the field only if the given value is less than the current value.
+
+	Update().
+		Min("lowScore", 150) // {"$min": {"lowScore": 150}}
+
+[$min]: https://www.mongodb.com/docs/manual/reference/operator/update/min/
+*/
+func (u *UpdateBuilder) Min(field any, value any) *UpdateBuilder {
+	return u.set(_min, field, value)
+}
+
+/*
+Max use mongo [$max] operator to update the field only if the given value is greater than the current value.
+
+	Update().
+		Max("highScore", 950) // {"$max": {"highScore": 950}}
+
+[$max]: https://www.mongodb.com/docs/manual/reference/operator/update/max/
+*/
+func (u *UpdateBuilder) Max(field any, value any) *UpdateBuilder {
+	return u.set(_max, field, value)
+}
+
+/*
+Rename use mongo [$rename] operator to rename the field. The new field is validated, the old field is usually a legacy
+field that is no longer in the source so a string is only checked for empty segments and segments beginning with '$'.
+
+	Update().
+		Rename("nmae", "name") // {"$rename": {"nmae": "name"}}
+
+[$rename]: https://www.mongodb.com/docs/manual/reference/operator/update/rename/
+*/
+func (u *UpdateBuilder) Rename(field any, newField any) *UpdateBuilder {
+	return u.set(rename, field, newField)
+}
+
+/*
+CurrentDate use mongo [$currentDate] operator to set the field to the current date.
+
+	Update().
+		CurrentDate("updatedAt") // {"$currentDate": {"updatedAt": true}}
+
+[$currentDate]: https://www.mongodb.com/docs/manual/reference/operator/update/currentDate/
+*/
+func (u *UpdateBuilder) CurrentDate(field any) *UpdateBuilder {
+	return u.set(currentDate, field, true)
+}
+
+/*
+CurrentTimestamp use mongo [$currentDate] operator to set the field to the current date as a timestamp.
+
+	Update().
+		CurrentTimestamp("updatedAt") // {"$currentDate": {"updatedAt": {"$type": "timestamp"}}}
+
+[$currentDate]: https://www.mongodb.com/docs/manual/reference/operator/update/currentDate/
+*/
+func (u *UpdateBuilder) CurrentTimestamp(field any) *UpdateBuilder {
+	return u.set(currentDate, field, bson.M{_type: "timestamp"})
+}
+
+/*
+SetOnInsert use mongo [$setOnInsert] operator to set the field only if the update results in an insert.
+
+	Update().
+		SetOnInsert("createdAt", time.Now()) // {"$setOnInsert": {"createdAt": ISODate(...)}}
+
+[$setOnInsert]: https://www.mongodb.com/docs/manual/reference/operator/update/setOnInsert/
+*/
+func (u *UpdateBuilder) SetOnInsert(field any, value any) *UpdateBuilder {
+	return u.set(setOnInsert, field, value)
+}
+
+/*
+Push use mongo [$push] operator to append the value to the array field.
+
+	Update().
+		Push("tags", "new") // {"$push": {"tags": "new"}}
+
+[$push]: https://www.mongodb.com/docs/manual/reference/operator/update/push/
+*/
+func (u *UpdateBuilder) Push(field any, value any) *UpdateBuilder {
+	return u.set(push, field, value)
+}
+
+/*
+PushEach use mongo [$push] operator with [$each] modifier to append multiple values to the array field.
+[$position], [$slice] and [$sort] modifiers can be set with PushPosition, PushSlice and PushSort options.
+
+	Update().
+		PushEach("scores", []int{90, 92}, PushSort(-1), PushSlice(3)) // {"$push": {"scores": {"$each": [90, 92], "$slice": 3, "$sort": -1}}}
+
+[$push]: https://www.mongodb.com/docs/manual/reference/operator/update/push/
+[$each]: https://www.mongodb.com/docs/manual/reference/operator/update/each/
+[$position]: https://www.mongodb.com/docs/manual/reference/operator/update/position/
+[$slice]: https://www.mongodb.com/docs/manual/reference/operator/update/slice/
+[$sort]: https://www.mongodb.com/docs/manual/reference/operator/update/sort/
+*/
+func (u *UpdateBuilder) PushEach(field any, values any, opts ...PushOptionFunc) *UpdateBuilder {
+	if values == nil || reflect.TypeOf(values).Kind() != reflect.Slice {
+		u.kyte.setError(ErrValueMustBeSlice)
+		return u
+	}
+
+	options := &pushOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	value := bson.D{{Key: each, Value: values}}
+	if options.position != nil {
+		value = append(value, bson.E{Key: position, Value: *options.position})
+	}
+
+	if options.slice != nil {
+		value = append(value, bson.E{Key: slice, Value: *options.slice})
+	}
+
+	if options.sort != nil {
+		value = append(value, bson.E{Key: sortStage, Value: options.sort})
+	}
+
+	return u.set(push, field, value)
+}
+
+/*
+AddToSet use mongo [$addToSet] operator to add the value to the array field unless it is already present.
+
+	Update().
+		AddToSet("tags", "new") // {"$addToSet": {"tags": "new"}}
+
+[$addToSet]: https://www.mongodb.com/docs/manual/reference/operator/update/addToSet/
+*/
+func (u *UpdateBuilder) AddToSet(field any, value any) *UpdateBuilder {
+	return u.set(addToSet, field, value)
+}
+
+/*
+AddToSetEach use mongo [$addToSet] operator with [$each] modifier to add multiple values to the array field unless they are already present.
+
+	Update().
+		AddToSetEach("tags", []string{"a", "b"}) // {"$addToSet": {"tags": {"$each": ["a", "b"]}}}
+
+[$addToSet]: https://www.mongodb.com/docs/manual/reference/operator/update/addToSet/
+[$each]: https://www.mongodb.com/docs/manual/reference/operator/update/each/
+*/
+func (u *UpdateBuilder) AddToSetEach(field any, values any) *UpdateBuilder {
+	if values == nil || reflect.TypeOf(values).Kind() != reflect.Slice {
+		u.kyte.setError(ErrValueMustBeSlice)
+		return u
+	}
+
+	return u.set(addToSet, field, bson.D{{Key: each, Value: values}})
+}
+
+/*
+Pull use mongo [$pull] operator to remove the array elements that match the given value or filter.
+Like ElemMatch, the filter is scoped to the array element and [Elem] can be used for arrays of scalar values.
+
+	Update().
+		Pull("tags", "old") // {"$pull": {"tags": "old"}}
+
+	Update().
+		Pull("scores", Filter().LessThan(kyte.Elem, 50)) // {"$pull": {"scores": {"$lt": 50}}}
+
+	Update().
+		Pull("items", Filter().Equal("name", "pen")) // {"$pull": {"items": {"name": {"$eq": "pen"}}}}
+
+[$pull]: https://www.mongodb.com/docs/manual/reference/operator/update/pull/
+*/
+func (u *UpdateBuilder) Pull(field any, value any) *UpdateBuilder {
+	if filter, ok := value.(*FilterBuilder); ok {
+		if filter == nil {
+			u.kyte.setError(ErrNilFilter)
+			return u
+		}
+
+		query, err := u.kyte.buildElemFilter(field, filter)
+		if err != nil {
+			u.kyte.setError(err)
+			return u
+		}
+		value = query
+	}
+
+	return u.set(pull, field, value)
+}
+
+/*
+PullAll use mongo [$pullAll] operator to remove all instances of the given values from the array field.
+
+	Update().
+		PullAll("scores", []int{0, 5}) // {"$pullAll": {"scores": [0, 5]}}
+
+[$pullAll]: https://www.mongodb.com/docs/manual/reference/operator/update/pullAll/
+*/
+func (u *UpdateBuilder) PullAll(field any, values any) *UpdateBuilder {
+	if values == nil || reflect.TypeOf(values).Kind() != reflect.Slice {
+		u.kyte.setError(ErrValueMustBeSlice)
+		return u
+	}
+
+	return u.set(pullAll, field, values)
+}
+
+/*
+Pop use mongo [$pop] operator to remove the first (-1) or the last (1) element of the array field.
+
+	Update().
+		Pop("scores", -1) // {"$pop": {"scores": -1}}
+
+[$pop]: https://www.mongodb.com/docs/manual/reference/operator/update/pop/
+*/
+func (u *UpdateBuilder) Pop(field any, value int) *UpdateBuilder {
+	if value != 1 && value != -1 {
+		u.kyte.setError(ErrInvalidPopValue)
+		return u
+	}
+
+	return u.set(pop, field, value)
+}
+
+/*
+ArrayFilter sets the filter of the identifier of a [FilteredPositional] path. The filter is scoped to the array element like [FilterBuilder.ElemMatch],
+its fields are prefixed with the identifier and [Elem] is replaced by the identifier for arrays of scalar values.
+
+	u := Update(Source(&order)).
+		Set(FilteredPositional(&order.Items, "elem", "qty"), 0).
+		ArrayFilter("elem", Filter().LessThan("qty", 5))
+
+	u.Build()        // {"$set": {"items.$[elem].qty": 0}}
+	u.ArrayFilters() // [{"elem.qty": {"$lt": 5}}]
+*/
+func (u *UpdateBuilder) ArrayFilter(identifier string, filter *FilterBuilder) *UpdateBuilder {
+	if !arrayFilterIdentifier.MatchString(identifier) {
+		u.kyte.setError(errors.Join(ErrInvalidArrayFilterIdentifier, fmt.Errorf("identifier: %q", identifier)))
+		return u
+	}
+
+	if filter == nil {
+		u.kyte.setError(ErrNilFilter)
+		return u
+	}
+
+	u.arrayFilters = append(u.arrayFilters, arrayFilter{identifier: identifier, filter: filter})
+	return u
+}
+
+/*
+Build returns the update document as bson.D, the fields are grouped by their operators. If there is an error, it will return nil and the first error.
+When array filters are set, every identifier of the paths must have a filter and every filter must be used by a path.
+*/
+func (u *UpdateBuilder) Build() (bson.D, error) {
+	query, _, err := u.build()
+	return query, err
+}
+
+/*
+ArrayFilters returns the array filters of the update, they can be passed to options.ArrayFilters of the mongo driver.
+*/
+func (u *UpdateBuilder) ArrayFilters() ([]any, error) {
+	_, identifiers, err := u.build()
+	if err != nil {
+		return nil, err
+	}
+
+	filters := make([]any, 0, len(u.arrayFilters))
+	for _, af := range u.arrayFilters {
+		if err := u.kyte.scopeElemFilter(identifiers[af.identifier], af.filter); err != nil {
+			u.kyte.setError(err)
+			return nil, err
+		}
+
+		query, err := af.filter.Build()
+		if err != nil {
+			u.kyte.setError(err)
+			return nil, err
+		}
+
+		filters = append(filters, prefixArrayFilter(query, af.identifier))
+	}
+
+	return filters, nil
+}
+
+func (u *UpdateBuilder) build() (bson.D, map[string]string, error) {
+	if u.kyte.hasErrors() {
+		return nil, nil, u.kyte.err
+	}
+
+	if len(u.operations) == 0 {
+		return nil, nil, ErrEmptyUpdateDocument
+	}
+
+	query := bson.D{}
+	paths := []string{}
+	for _, opt := range u.operations {
+		fieldName, err := u.fieldName(&opt)
+		if err != nil {
+			u.kyte.setError(err)
+			return nil, nil, err
+		}
+
+		if opt.operator == rename {
+			newFieldName, err := u.kyte.resolveField(opt.value)
+			if err != nil {
+				u.kyte.setError(err)
+				return nil, nil, err
+			}
+			opt.value = newFieldName
+
+			if err := checkUpdatePath(paths, newFieldName); err != nil {
+				u.kyte.setError(err)
+				return nil, nil, err
+			}
+			paths = append(paths, newFieldName)
+		}
+
+		if err := checkUpdatePath(paths, fieldName); err != nil {
+			u.kyte.setError(err)
+			return nil, nil, err
+		}
+		paths = append(paths, fieldName)
+
+		if opt.value != nil && reflect.TypeOf(opt.value).Kind() == reflect.Ptr {
+			opt.value = reflect.ValueOf(opt.value).Elem().Interface()
+		}
+
+		query = appendToOperator(query, opt.operator, bson.E{Key: fieldName, Value: opt.value})
+	}
+
+	identifiers, err := u.checkArrayFilters(paths)
+	if err != nil {
+		u.kyte.setError(err)
+		return nil, nil, err
+	}
+
+	return query, identifiers, nil
+}
+
+/*
+checkArrayFilters returns the identifiers of the paths with their arrays, the identifiers and the array filters must match each other.
+*/
+func (u *UpdateBuilder) checkArrayFilters(paths []string) (map[string]string, error) {
+	identifiers := map[string]string{}
+	for _, path := range paths {
+		for identifier, array := range arrayFilterIdentifiers(path) {
+			identifiers[identifier] = array
+		}
+	}
+
+	if len(u.arrayFilters) == 0 {
+		return identifiers, nil
+	}
+
+	defined := map[string]bool{}
+	for _, af := range u.arrayFilters {
+		if _, ok := identifiers[af.identifier]; !ok {
+			return nil, errors.Join(ErrUnusedArrayFilter, fmt.Errorf("identifier: %s", af.identifier))
+		}
+		defined[af.identifier] = true
+	}
+
+	for _, path := range paths {
+		for identifier := range arrayFilterIdentifiers(path) {
+			if !defined[identifier] {
+				return nil, errors.Join(ErrMissingArrayFilter, fmt.Errorf("identifier: %s path: %s", identifier, path))
+			}
+		}
+	}
+
+	return identifiers, nil
+}
+
+/*
+prefixArrayFilter prefixes the fields of the query with the identifier, the fields of the logical operators are prefixed recursively.
+*/
+func prefixArrayFilter(query bson.D, identifier string) bson.D {
+	prefixed := make(bson.D, 0, len(query))
+	for _, e := range query {
+		switch {
+		case e.Key == Elem:
+			e.Key = identifier
+		case e.Key == and || e.Key == or || e.Key == nor:
+			e.Value = prefixConditions(e.Value, identifier)
+		case !strings.HasPrefix(e.Key, "$"):
+			e.Key = identifier + "." + e.Key
+		}
+		prefixed = append(prefixed, e)
+	}
+	return prefixed
+}
+
+func prefixConditions(value any, identifier string) any {
+	conditions, ok := value.(bson.A)
+	if !ok {
+		return value
+	}
+
+	prefixed := make(bson.A, 0, len(conditions))
+	for _, condition := range conditions {
+		switch c := condition.(type) {
+		case bson.D:
+			prefixed = append(prefixed, prefixArrayFilter(c, identifier))
+		case bson.M:
+			m := bson.M{}
+			for _, e := range prefixArrayFilter(mapToDocument(c), identifier) {
+				m[e.Key] = e.Value
+			}
+			prefixed = append(prefixed, m)
+		default:
+			prefixed = append(prefixed, condition)
+		}
+	}
+	return prefixed
+}
+
+/*
+fieldName validates the field of the operation and returns its name, the old name of a rename is not looked up in the source.
+*/
+func (u *UpdateBuilder) fieldName(opt *operation) (string, error) {
+	if name, ok := opt.field.(string); ok && opt.operator == rename {
+		if name == "" {
+			return "", ErrEmptyField
+		}
+		return name, checkFieldPath(name)
+	}
+
+	if err := u.kyte.validate(opt); err != nil {
+		return "", err
+	}
+
+	return u.kyte.getFieldName(opt.field)
+}
+
+func (u *UpdateBuilder) set(operator string, field any, value any) *UpdateBuilder {
+	u.operations = append(u.operations, operation{
+		operator:        operator,
+		field:           field,
+		value:           value,
+		isFieldRequired: true,
+	})
+
+	return u
+}
+
+func appendToOperator(query bson.D, operator string, e bson.E) bson.D {
+	for i := range query {
+		if query[i].Key == operator {
+			query[i].Value = append(query[i].Value.(bson.D), e)
+			return query
+		}
+	}
+
+	return append(query, bson.E{Key: operator, Value: bson.D{e}})
+}
+
+/*
+checkUpdatePath returns an error if the given path conflicts with one of the paths, mongo rejects such updates.
+*/
+func checkUpdatePath(paths []string, path string) error {
+	if conflict, ok := conflictingPath(paths, path); ok {
+		return errors.Join(ErrUpdatePathConflict, fmt.Errorf("path: %s conflicts with: %s", path, conflict))
+	}
+	return nil
+}
+
+/*
+conflictingPath returns the path that is the same with or a parent or a child of the given path.
+*/
+func conflictingPath(paths []string, path string) (string, bool) {
+	for _, p := range paths {
+		if p == path || strings.HasPrefix(p, path+".") || strings.HasPrefix(path, p+".") {
+			return p, true
+		}
+	}
+	return "", false
+}
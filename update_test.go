@@ -0,0 +1,206 @@
+package kyte_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aaydin-tr/kyte"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func testUpdateJSON(t *testing.T, u bson.D, target string) {
+	t.Helper()
+
+	uByte, err := bson.MarshalExtJSON(u, false, false)
+	if err != nil {
+		t.Errorf("Update.Build should return a valid document: %v", err)
+	}
+
+	if string(uByte) != target {
+		t.Errorf("Update.Build should return value %v, got %v", target, string(uByte))
+	}
+}
+
+func TestUpdate_Build(t *testing.T) {
+	t.Parallel()
+
+	type Item struct {
+		Name string `bson:"name"`
+		Qty  int    `bson:"qty"`
+	}
+
+	type User struct {
+		Name      string   `bson:"name"`
+		Nickname  string   `bson:"nickname"`
+		Age       int      `bson:"age"`
+		Score     float64  `bson:"score"`
+		LowScore  int      `bson:"lowScore"`
+		HighScore int      `bson:"highScore"`
+		Tags      []string `bson:"tags"`
+		Scores    []int    `bson:"scores"`
+		Items     []Item   `bson:"items"`
+		CreatedAt string   `bson:"createdAt"`
+		UpdatedAt string   `bson:"updatedAt"`
+		SyncedAt  string   `bson:"syncedAt"`
+		Legacy    string   `bson:"legacy"`
+	}
+
+	t.Run("without source", func(t *testing.T) {
+		u, err := kyte.Update().
+			Set("name", "John").
+			Inc("age", 1).
+			Set("surname", "Doe").
+			Unset("nickname").
+			Build()
+		if err != nil {
+			t.Errorf("Update.Build should not return error: %v", err)
+		}
+
+		testUpdateJSON(t, u, `{"$set":{"name":"John","surname":"Doe"},"$inc":{"age":1},"$unset":{"nickname":""}}`)
+	})
+
+	t.Run("with source", func(t *testing.T) {
+		var user User
+		name := "John"
+		u, err := kyte.Update(kyte.Source(&user)).
+			Set(&user.Name, &name).
+			Unset(&user.Nickname).
+			Inc(&user.Age, 1).
+			Mul(&user.Score, 1.5).
+			Min(&user.LowScore, 10).
+			Max(&user.HighScore, 90).
+			Rename("legacy", &user.CreatedAt).
+			CurrentDate(&user.UpdatedAt).
+			CurrentTimestamp(&user.SyncedAt).
+			Build()
+		if err != nil {
+			t.Errorf("Update.Build should not return error: %v", err)
+		}
+
+		testUpdateJSON(t, u, `{"$set":{"name":"John"},"$unset":{"nickname":""},"$inc":{"age":1},"$mul":{"score":1.5},`+
+			`"$min":{"lowScore":10},"$max":{"highScore":90},"$rename":{"legacy":"createdAt"},`+
+			`"$currentDate":{"updatedAt":true,"syncedAt":{"$type":"timestamp"}}}`)
+	})
+
+	t.Run("array operators", func(t *testing.T) {
+		var user User
+		u, err := kyte.Update(kyte.Source(&user)).
+			PushEach(&user.Scores, []int{90, 92}, kyte.PushPosition(0), kyte.PushSlice(-5), kyte.PushSort(-1)).
+			AddToSetEach(&user.Tags, []string{"a", "b"}).
+			Pull(&user.Items, kyte.Filter().Equal("name", "pen").LessThan("qty", 1)).
+			Build()
+		if err != nil {
+			t.Errorf("Update.Build should not return error: %v", err)
+		}
+
+		testUpdateJSON(t, u, `{"$push":{"scores":{"$each":[90,92],"$position":0,"$slice":-5,"$sort":-1}},`+
+			`"$addToSet":{"tags":{"$each":["a","b"]}},`+
+			`"$pull":{"items":{"name":{"$eq":"pen"},"qty":{"$lt":1}}}}`)
+
+		u, err = kyte.Update(kyte.Source(&user)).
+			Push(&user.Tags, "new").
+			Pull(&user.Scores, kyte.Filter().LessThan(kyte.Elem, 50)).
+			Pop(&user.Items, -1).
+			SetOnInsert(&user.CreatedAt, "now").
+			Build()
+		if err != nil {
+			t.Errorf("Update.Build should not return error: %v", err)
+		}
+
+		testUpdateJSON(t, u, `{"$push":{"tags":"new"},"$pull":{"scores":{"$lt":50}},"$pop":{"items":-1},"$setOnInsert":{"createdAt":"now"}}`)
+
+		u, err = kyte.Update().
+			AddToSet("tags", "new").
+			PullAll("scores", []int{0, 5}).
+			Build()
+		if err != nil {
+			t.Errorf("Update.Build should not return error: %v", err)
+		}
+
+		testUpdateJSON(t, u, `{"$addToSet":{"tags":"new"},"$pullAll":{"scores":[0,5]}}`)
+	})
+
+	t.Run("invalid field", func(t *testing.T) {
+		var user User
+		_, err := kyte.Update(kyte.Source(&user)).Set("surname", "Doe").Build()
+		if !errors.Is(err, kyte.ErrNotValidFieldForQuery) {
+			t.Errorf("Update.Build should return error %v, got %v", kyte.ErrNotValidFieldForQuery, err)
+		}
+
+		_, err = kyte.Update(kyte.Source(&user)).Pull(&user.Items, kyte.Filter().Equal("title", "pen")).Build()
+		if !errors.Is(err, kyte.ErrNotValidFieldForQuery) {
+			t.Errorf("Update.Build should return error %v, got %v", kyte.ErrNotValidFieldForQuery, err)
+		}
+
+		_, err = kyte.Update(kyte.Source(&user), kyte.ValidateField(false)).Set("surname", "Doe").Build()
+		if err != nil {
+			t.Errorf("Update.Build should not return error: %v", err)
+		}
+	})
+
+	t.Run("rename legacy field", func(t *testing.T) {
+		var user User
+		u, err := kyte.Update(kyte.Source(&user)).Rename("nmae", &user.Name).Build()
+		if err != nil {
+			t.Errorf("Update.Build should not return error: %v", err)
+		}
+
+		testUpdateJSON(t, u, `{"$rename":{"nmae":"name"}}`)
+
+		for _, field := range []string{"$where", "profile..name", "profile.$"} {
+			_, err = kyte.Update(kyte.Source(&user)).Rename(field, &user.Name).Build()
+			if !errors.Is(err, kyte.ErrInvalidFieldPath) {
+				t.Errorf("Update.Build should return error %v for %s, got %v", kyte.ErrInvalidFieldPath, field, err)
+			}
+		}
+
+		_, err = kyte.Update(kyte.Source(&user)).Rename("name", "surname").Build()
+		if !errors.Is(err, kyte.ErrNotValidFieldForQuery) {
+			t.Errorf("Update.Build should return error %v, got %v", kyte.ErrNotValidFieldForQuery, err)
+		}
+	})
+
+	t.Run("conflicting paths", func(t *testing.T) {
+		_, err := kyte.Update().Set("name", "John").Unset("name").Build()
+		if !errors.Is(err, kyte.ErrUpdatePathConflict) {
+			t.Errorf("Update.Build should return error %v, got %v", kyte.ErrUpdatePathConflict, err)
+		}
+
+		_, err = kyte.Update().Set("address", bson.M{}).Set("address.city", "Izmir").Build()
+		if !errors.Is(err, kyte.ErrUpdatePathConflict) {
+			t.Errorf("Update.Build should return error %v, got %v", kyte.ErrUpdatePathConflict, err)
+		}
+
+		_, err = kyte.Update().Set("address.city", "Izmir").Set("address.country", "TR").Build()
+		if err != nil {
+			t.Errorf("Update.Build should not return error: %v", err)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := kyte.Update().Pop("scores", 2).Build()
+		if err != kyte.ErrInvalidPopValue {
+			t.Errorf("Update.Build should return error %v, got %v", kyte.ErrInvalidPopValue, err)
+		}
+
+		_, err = kyte.Update().PushEach("scores", 1).Build()
+		if err != kyte.ErrValueMustBeSlice {
+			t.Errorf("Update.Build should return error %v, got %v", kyte.ErrValueMustBeSlice, err)
+		}
+
+		_, err = kyte.Update().PullAll("scores", nil).Build()
+		if err != kyte.ErrValueMustBeSlice {
+			t.Errorf("Update.Build should return error %v, got %v", kyte.ErrValueMustBeSlice, err)
+		}
+
+		_, err = kyte.Update().Set(nil, 1).Build()
+		if err != kyte.ErrNilField {
+			t.Errorf("Update.Build should return error %v, got %v", kyte.ErrNilField, err)
+		}
+
+		_, err = kyte.Update().Build()
+		if err != kyte.ErrEmptyUpdateDocument {
+			t.Errorf("Update.Build should return error %v, got %v", kyte.ErrEmptyUpdateDocument, err)
+		}
+	})
+}
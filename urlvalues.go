@@ -0,0 +1,263 @@
+package kyte
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const sortParam = "sort"
+
+var paramKey = regexp.MustCompile(`^([^\[\]]+)(?:\[([a-z]+)\])?$`)
+
+// paramOperators are the operators of the query string parameters such as age[gte]=18.
+var paramOperators = map[string]string{
+	"eq":     eq,
+	"ne":     ne,
+	"gt":     gt,
+	"gte":    gte,
+	"lt":     lt,
+	"lte":    lte,
+	"in":     in,
+	"nin":    nin,
+	"regex":  regx,
+	"exists": exists,
+}
+
+/*
+ParamError is the error of a query string parameter, the errors of FromURLValues are joined so each parameter has its own ParamError.
+*/
+type ParamError struct {
+	Param string
+	Err   error
+}
+
+func (e *ParamError) Error() string {
+	return fmt.Sprintf("param: %s %v", e.Param, e.Err)
+}
+
+func (e *ParamError) Unwrap() error {
+	return e.Err
+}
+
+/*
+AllowOperators is an option function that limits the operators of the parsed filters, the operators can be given with or without the dollar sign.
+
+	kyte.FromURLValues(values, kyte.AllowOperators("eq", "gte", "lte", "in"))
+*/
+func AllowOperators(operators ...string) OptionFunc {
+	return func(o *Options) {
+		o.allowedOperators = append(o.allowedOperators, operators...)
+		o.scoped |= allowOperatorsOption
+	}
+}
+
+/*
+AllowFields is an option function that limits the fields of the parsed filters and sorts, the fields can be strings or pointers of the source struct fields.
+
+	kyte.FromURLValues(values, kyte.Source(&user), kyte.AllowFields(&user.Age, &user.Status))
+*/
+func AllowFields(fields ...any) OptionFunc {
+	return func(o *Options) {
+		o.allowedFields = append(o.allowedFields, fields...)
+		o.scoped |= allowFieldsOption
+	}
+}
+
+/*
+IgnoreParams is an option function that skips the given parameters in FromURLValues, the sort parameter is always skipped.
+
+	kyte.FromURLValues(values, kyte.IgnoreParams("page", "limit"))
+*/
+func IgnoreParams(params ...string) OptionFunc {
+	return func(o *Options) {
+		o.ignoredParams = append(o.ignoredParams, params...)
+		o.scoped |= ignoreParamsOption
+	}
+}
+
+/*
+FromURLValues creates a filter from the query string parameters. A parameter is in the form of field or field[operator],
+the values are converted to the types of the source struct fields.
+
+	// ?age[gte]=18&status[in]=active,pending&name[regex]=^J&verified=true&sort=-createdAt
+	kyte.FromURLValues(r.URL.Query(), kyte.Source(&user), kyte.AllowOperators("eq", "gte", "in", "regex"))
+	// {"age": {"$gte": 18}, "name": {"$regex": "^J"}, "status": {"$in": ["active", "pending"]}, "verified": {"$eq": true}}
+
+Supported operators are eq, ne, gt, gte, lt, lte, in, nin, regex and exists. The values of in and nin are separated by commas.
+The parameters are applied in the order of their names and the error has a [ParamError] for each invalid parameter, the filter is nil if there is an error.
+The field names are untrusted input, so a field with an empty segment or a segment beginning with $ such as $where is rejected even without a source.
+*/
+func FromURLValues(values url.Values, opts ...OptionFunc) (*FilterBuilder, error) {
+	options := newOptions(opts)
+	if err := options.unsupported(allowOperatorsOption | allowFieldsOption | ignoreParamsOption); err != nil {
+		return nil, err
+	}
+
+	f := newFilter(options)
+	allowed, err := newAllowlist(f.kyte, options)
+	if err != nil {
+		return nil, err
+	}
+
+	params := make([]string, 0, len(values))
+	for param := range values {
+		if param != sortParam && !contains(options.ignoredParams, param) {
+			params = append(params, param)
+		}
+	}
+	sort.Strings(params)
+
+	var errs []error
+	for _, param := range params {
+		if err := applyParam(f, allowed, param, values[param]); err != nil {
+			errs = append(errs, &ParamError{Param: param, Err: err})
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return f, nil
+}
+
+/*
+SortFromURLValues creates a sort from the sort parameter, the fields are separated by commas and a field with the minus prefix is sorted in descending order.
+
+	// ?sort=-createdAt,name
+	kyte.SortFromURLValues(r.URL.Query(), kyte.Source(&user)) // {"createdAt": -1, "name": 1}
+
+The sort is nil if there is an error.
+*/
+func SortFromURLValues(values url.Values, opts ...OptionFunc) (*SortBuilder, error) {
+	options := newOptions(opts)
+	if err := options.unsupported(allowFieldsOption); err != nil {
+		return nil, err
+	}
+
+	s := newSort(options)
+	allowed, err := newAllowlist(s.kyte, options)
+	if err != nil {
+		return nil, err
+	}
+
+	var errs []error
+	for _, value := range values[sortParam] {
+		for _, field := range strings.Split(value, ",") {
+			field = strings.TrimSpace(field)
+			desc := strings.HasPrefix(field, "-")
+			field = strings.TrimLeft(field, "+-")
+
+			path, err := allowed.field(field)
+			if err != nil {
+				errs = append(errs, &ParamError{Param: sortParam, Err: err})
+				continue
+			}
+
+			if desc {
+				s.Desc(path)
+			} else {
+				s.Asc(path)
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return s, nil
+}
+
+func applyParam(f *FilterBuilder, allowed *allowlist, param string, values []string) error {
+	match := paramKey.FindStringSubmatch(param)
+	if match == nil {
+		return ErrInvalidParam
+	}
+
+	name := match[2]
+	if name == "" {
+		name = "eq"
+	}
+
+	operator, ok := paramOperators[name]
+	if !ok {
+		return errors.Join(ErrUnsupportedParamOperator, fmt.Errorf("operator: %s", name))
+	}
+
+	if err := allowed.operator(operator); err != nil {
+		return err
+	}
+
+	path, err := allowed.field(match[1])
+	if err != nil {
+		return err
+	}
+
+	raws := values
+	if operator == in || operator == nin {
+		raws = nil
+		for _, value := range values {
+			raws = append(raws, strings.Split(value, ",")...)
+		}
+	}
+
+	return applyCondition(f, operator, path, rawLiterals(raws))
+}
+
+/*
+allowlist holds the allowed operators and the resolved paths of the allowed fields, an empty list allows all of them.
+The fields are validated against the source struct of the kyte, elem allows the [Elem] field of an element filter.
+*/
+type allowlist struct {
+	kyte      *kyte
+	operators []string
+	fields    []string
+	elem      bool
+}
+
+func newAllowlist(k *kyte, options *Options) (*allowlist, error) {
+	a := &allowlist{kyte: k}
+	for _, operator := range options.allowedOperators {
+		a.operators = append(a.operators, "$"+strings.TrimPrefix(operator, "$"))
+	}
+
+	for _, field := range options.allowedFields {
+		path, err := k.resolveField(field)
+		if err != nil {
+			return nil, errors.Join(ErrFieldNotAllowed, err)
+		}
+		a.fields = append(a.fields, path)
+	}
+
+	return a, nil
+}
+
+func (a *allowlist) operator(operator string) error {
+	if len(a.operators) > 0 && !contains(a.operators, operator) {
+		return errors.Join(ErrOperatorNotAllowed, fmt.Errorf("operator: %s", operator))
+	}
+	return nil
+}
+
+func (a *allowlist) field(field string) (string, error) {
+	if field == "" {
+		return "", ErrEmptyField
+	}
+
+	if !(a.elem && field == Elem) {
+		if err := checkFieldPath(field); err != nil {
+			return "", err
+		}
+	}
+
+	if len(a.fields) > 0 && !contains(a.fields, field) {
+		return "", errors.Join(ErrFieldNotAllowed, fmt.Errorf("field: %s", field))
+	}
+
+	return a.kyte.resolveField(field)
+}
@@ -0,0 +1,171 @@
+package kyte_test
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aaydin-tr/kyte"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestFromURLValues(t *testing.T) {
+	t.Parallel()
+
+	t.Run("operators and types", func(t *testing.T) {
+		var user TestUser
+		values, _ := url.ParseQuery("age[gte]=18&status[in]=active,pending&name[regex]=^J&active=true&sort=-created_at" +
+			"&_id=5f1d7f8e9d3b2a1c4e6f8a9b&created_at[lt]=2024-01-02&score[ne]=1.5&tags[exists]=false")
+
+		f, err := kyte.FromURLValues(values, kyte.Source(&user))
+
+		id, _ := primitive.ObjectIDFromHex("5f1d7f8e9d3b2a1c4e6f8a9b")
+		target := bson.D{
+			{Key: "_id", Value: bson.M{"$eq": id}},
+			{Key: "active", Value: bson.M{"$eq": true}},
+			{Key: "age", Value: bson.M{"$gte": 18}},
+			{Key: "created_at", Value: bson.M{"$lt": time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)}},
+			{Key: "name", Value: bson.M{"$regex": "^J"}},
+			{Key: "score", Value: bson.M{"$ne": 1.5}},
+			{Key: "status", Value: bson.M{"$in": []any{"active", "pending"}}},
+			{Key: "tags", Value: bson.M{"$exists": false}},
+		}
+		testParsedFilter(t, "FromURLValues", f, err, target)
+	})
+
+	t.Run("without source", func(t *testing.T) {
+		values := url.Values{"age[gt]": {"18"}, "page": {"2"}}
+		f, err := kyte.FromURLValues(values, kyte.IgnoreParams("page"))
+		if err != nil {
+			t.Errorf("FromURLValues should not return error: %v", err)
+		}
+
+		q, _ := f.Build()
+		target := bson.D{{Key: "age", Value: bson.M{"$gt": "18"}}}
+		if !reflect.DeepEqual(q, target) {
+			t.Errorf("FromURLValues should return value %v, got %v", target, q)
+		}
+	})
+
+	t.Run("per parameter errors", func(t *testing.T) {
+		var user TestUser
+		values := url.Values{
+			"age[gte]":     {"eighteen"},
+			"name[regex]":  {"^J"},
+			"status[near]": {"x"},
+			"email":        {"joe@kyte.dev"},
+			"score[":       {"1"},
+			"active":       {"true", "false"},
+			"status":       {"active"},
+		}
+
+		_, err := kyte.FromURLValues(values, kyte.Source(&user), kyte.AllowOperators("eq", "$gte", "in"), kyte.AllowFields(&user.Age, "name", "status", "score", "active"))
+
+		tests := map[string]error{
+			"age[gte]":     kyte.ErrInvalidParamValue,
+			"name[regex]":  kyte.ErrOperatorNotAllowed,
+			"status[near]": kyte.ErrUnsupportedParamOperator,
+			"email":        kyte.ErrFieldNotAllowed,
+			"score[":       kyte.ErrInvalidParam,
+			"active":       kyte.ErrInvalidParamValue,
+		}
+
+		var params []string
+		for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+			var paramErr *kyte.ParamError
+			if !errors.As(e, &paramErr) {
+				t.Errorf("FromURLValues should return ParamError, got %v", e)
+				continue
+			}
+
+			params = append(params, paramErr.Param)
+			if !errors.Is(paramErr, tests[paramErr.Param]) {
+				t.Errorf("FromURLValues should return error %v for %s, got %v", tests[paramErr.Param], paramErr.Param, paramErr.Err)
+			}
+		}
+
+		if len(params) != len(tests) {
+			t.Errorf("FromURLValues should return %d errors, got %v", len(tests), params)
+		}
+
+		_, err = kyte.FromURLValues(url.Values{"password": {"secret"}}, kyte.Source(&user))
+		if !errors.Is(err, kyte.ErrNotValidFieldForQuery) {
+			t.Errorf("FromURLValues should return error %v, got %v", kyte.ErrNotValidFieldForQuery, err)
+		}
+
+		_, err = kyte.FromURLValues(url.Values{}, kyte.Source(&user), kyte.AllowFields("password"))
+		if !errors.Is(err, kyte.ErrFieldNotAllowed) {
+			t.Errorf("FromURLValues should return error %v, got %v", kyte.ErrFieldNotAllowed, err)
+		}
+	})
+
+	t.Run("operator injection", func(t *testing.T) {
+		for _, param := range []string{"$where", "profile.$where", "profile..age", "age.", "$or[in]"} {
+			f, err := kyte.FromURLValues(url.Values{param: {"sleep(1)"}, "age": {"18"}})
+			if !errors.Is(err, kyte.ErrInvalidFieldPath) {
+				t.Errorf("FromURLValues should return error %v for %s, got %v", kyte.ErrInvalidFieldPath, param, err)
+			}
+
+			if f != nil {
+				t.Errorf("FromURLValues should return nil filter for %s", param)
+			}
+		}
+	})
+
+	t.Run("parser options in the other builders", func(t *testing.T) {
+		builds := map[string]func() (any, error){
+			"filter":     func() (any, error) { return kyte.Filter(kyte.AllowFields("age")).Equal("name", "John").Build() },
+			"update":     func() (any, error) { return kyte.Update(kyte.AllowOperators("eq")).Set("name", "John").Build() },
+			"sort":       func() (any, error) { return kyte.Sort(kyte.IgnoreParams("page")).Asc("name").Build() },
+			"projection": func() (any, error) { return kyte.Projection(kyte.AllowFields("age")).Include("name").Build() },
+			"pipeline":   func() (any, error) { return kyte.Pipeline(kyte.AllowOperators("eq")).Limit(1).Build() },
+			"sort params": func() (any, error) {
+				return kyte.SortFromURLValues(url.Values{"sort": {"name"}}, kyte.AllowOperators("eq"))
+			},
+			"rsql": func() (any, error) { return kyte.FromRSQL("name==John", kyte.IgnoreParams("page")) },
+		}
+
+		for name, build := range builds {
+			if _, err := build(); !errors.Is(err, kyte.ErrUnsupportedOption) {
+				t.Errorf("%s should return error %v, got %v", name, kyte.ErrUnsupportedOption, err)
+			}
+		}
+	})
+}
+
+func TestSortFromURLValues(t *testing.T) {
+	t.Parallel()
+
+	var user TestUser
+	s, err := kyte.SortFromURLValues(url.Values{"sort": {"-created_at,+name,age"}}, kyte.Source(&user))
+	if err != nil {
+		t.Errorf("SortFromURLValues should not return error: %v", err)
+	}
+
+	q, err := s.Build()
+	if err != nil {
+		t.Errorf("Sort.Build should not return error: %v", err)
+	}
+
+	target := bson.D{{Key: "created_at", Value: -1}, {Key: "name", Value: 1}, {Key: "age", Value: 1}}
+	if !reflect.DeepEqual(q, target) {
+		t.Errorf("SortFromURLValues should return value %v, got %v", target, q)
+	}
+
+	s, err = kyte.SortFromURLValues(url.Values{"sort": {"-password"}}, kyte.Source(&user))
+	if !errors.Is(err, kyte.ErrNotValidFieldForQuery) {
+		t.Errorf("SortFromURLValues should return error %v, got %v", kyte.ErrNotValidFieldForQuery, err)
+	}
+
+	if s != nil {
+		t.Errorf("SortFromURLValues should return nil sort on error")
+	}
+
+	_, err = kyte.SortFromURLValues(url.Values{"sort": {"-$natural"}})
+	if !errors.Is(err, kyte.ErrInvalidFieldPath) {
+		t.Errorf("SortFromURLValues should return error %v, got %v", kyte.ErrInvalidFieldPath, err)
+	}
+}